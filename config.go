@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/whaios/goshowdoc/log"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile 项目配置文件的名称，从当前目录开始逐层向上查找
+const ConfigFile = ".goshowdoc.yaml"
+
+// Config 项目配置文件，命令行参数和 GOSHOWDOC_* 环境变量优先于配置文件。
+// 配置文件中的相对路径都相对于配置文件所在的目录，ShowDoc 项目的配置支持 ${ENV} 格式的环境变量，避免在文件中保存认证凭证。
+//
+//	dirs: [./handler]
+//	route_dirs: [./router]
+//	exclude: ["*_mock.go", internal/debug]
+//	catalog_prefix: 开放接口
+//	exclude_tags: [internal]
+//	vars:
+//	  BASEURL: https://api.example.com
+//	project:
+//	  host: https://www.showdoc.com.cn
+//	  api_key: ${SHOWDOC_API_KEY}
+//	  api_token: ${SHOWDOC_API_TOKEN}
+//	projects:
+//	  - name: 后台
+//	    catalogs: [开放接口/后台管理]
+//	    tags: [admin]
+//	    api_key: ${ADMIN_API_KEY}
+//	    api_token: ${ADMIN_API_TOKEN}
+type Config struct {
+	Dirs          []string          `yaml:"dirs"`           // 搜索 Go 源码文件的目录
+	RouteDirs     []string          `yaml:"route_dirs"`     // 路由注册代码所在的目录
+	Exclude       []string          `yaml:"exclude"`        // 不解析注释的文件，glob 格式
+	CatalogPrefix string            `yaml:"catalog_prefix"` // 所有文档的目录前缀，多层目录用斜杆隔开
+	IncludeTags   []string          `yaml:"include_tags"`   // 只保留有任意一个标签的文档
+	ExcludeTags   []string          `yaml:"exclude_tags"`   // 去掉有任意一个标签的文档
+	Vars          map[string]string `yaml:"vars"`           // 文档中 RunApi 环境变量的值，如：BASEURL，导出文档时使用
+	Project       ProjectConfig     `yaml:"project"`        // 更新文档的 ShowDoc 项目，projects 以外的文档更新到该项目
+	Projects      []*ProjectConfig  `yaml:"projects"`       // 按目录前缀或标签更新到其他 ShowDoc 项目的文档
+
+	fileName string
+}
+
+// ProjectConfig 配置文件中的 ShowDoc 项目
+type ProjectConfig struct {
+	Name     string `yaml:"name"`      // 项目名称，用于输出日志，projects 中的项目必须设置
+	Host     string `yaml:"host"`      // ShowDoc 地址，projects 中的项目为空时使用 project 的地址
+	ApiKey   string `yaml:"api_key"`   // 开放 API 认证凭证
+	ApiToken string `yaml:"api_token"` // 开放 API 认证凭证
+	ItemId   string `yaml:"item_id"`   // 项目 id
+
+	Catalogs []string `yaml:"catalogs"` // 目录前缀，添加 catalog_prefix 之后的目录和任意一个前缀匹配时更新到该项目，只用于 projects 中的项目
+	Tags     []string `yaml:"tags"`     // 标签，文档有任意一个标签时更新到该项目，只用于 projects 中的项目
+}
+
+// FindConfig 从指定目录开始逐层向上查找配置文件，没有找到时返回空字符串
+func FindConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		fileName := filepath.Join(dir, ConfigFile)
+		if _, err := os.Stat(fileName); err == nil {
+			return fileName, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadConfig 读取配置文件，不支持的配置项会返回错误。相对路径转换为当前目录的相对路径
+//
+// @param fileName 为空时返回空的配置
+func LoadConfig(fileName string) (*Config, error) {
+	cfg := &Config{fileName: fileName}
+	if fileName == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("解析配置文件 %s 出错: %v", fileName, err)
+	}
+
+	base := filepath.Dir(fileName)
+	for i, dir := range cfg.Dirs {
+		cfg.Dirs[i] = rebasePath(base, dir)
+	}
+	for i, dir := range cfg.RouteDirs {
+		cfg.RouteDirs[i] = rebasePath(base, dir)
+	}
+	for i, pattern := range cfg.Exclude {
+		// 没有斜杆的格式匹配文件名或目录名，和配置文件的位置无关
+		if strings.Contains(pattern, "/") {
+			pattern = filepath.ToSlash(rebasePath(base, pattern))
+			if !strings.Contains(pattern, "/") {
+				pattern = "./" + pattern
+			}
+			cfg.Exclude[i] = pattern
+		}
+	}
+	cfg.CatalogPrefix = strings.Trim(cfg.CatalogPrefix, "/")
+	for _, project := range append([]*ProjectConfig{&cfg.Project}, cfg.Projects...) {
+		if project == nil {
+			continue
+		}
+		project.Host = os.ExpandEnv(project.Host)
+		project.ApiKey = os.ExpandEnv(project.ApiKey)
+		project.ApiToken = os.ExpandEnv(project.ApiToken)
+		project.ItemId = os.ExpandEnv(project.ItemId)
+		for i, catalog := range project.Catalogs {
+			project.Catalogs[i] = strings.Trim(catalog, "/")
+		}
+	}
+	return cfg, nil
+}
+
+// FileName 配置文件的路径，没有配置文件时为空
+func (c *Config) FileName() string {
+	return c.fileName
+}
+
+var configVarPattern = regexp.MustCompile(`^\w+$`)
+
+// Validate 检查配置项是否有效，返回所有的错误
+func (c *Config) Validate() error {
+	var errs []error
+	for _, dirs := range [][]string{c.Dirs, c.RouteDirs} {
+		for _, dir := range dirs {
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				errs = append(errs, fmt.Errorf("目录不存在: %s", dir))
+			}
+		}
+	}
+	for _, pattern := range c.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("exclude 格式错误: %s", pattern))
+		}
+	}
+	for name := range c.Vars {
+		if !configVarPattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("vars 变量名只能包含字母、数字和下划线: %s", name))
+		}
+	}
+	if len(c.Project.Catalogs) > 0 || len(c.Project.Tags) > 0 {
+		errs = append(errs, errors.New("project 为默认项目，不能设置 catalogs 或 tags"))
+	}
+	errs = append(errs, validateHost("project", c.Project.Host)...)
+	names := make(map[string]bool)
+	for i, project := range c.Projects {
+		if project == nil {
+			errs = append(errs, fmt.Errorf("projects 第 %d 个项目为空", i+1))
+			continue
+		}
+		field := fmt.Sprintf("projects[%s]", project.Name)
+		switch {
+		case project.Name == "":
+			errs = append(errs, fmt.Errorf("projects 第 %d 个项目没有设置 name", i+1))
+		case names[project.Name]:
+			errs = append(errs, fmt.Errorf("projects 中的项目重名: %s", project.Name))
+		}
+		names[project.Name] = true
+		if len(project.Catalogs) == 0 && len(project.Tags) == 0 {
+			errs = append(errs, fmt.Errorf("%s 没有设置 catalogs 或 tags", field))
+		}
+		if project.ApiKey == "" || project.ApiToken == "" {
+			errs = append(errs, fmt.Errorf("%s 没有设置 api_key 或 api_token", field))
+		}
+		errs = append(errs, validateHost(field, project.Host)...)
+	}
+	return errors.Join(errs...)
+}
+
+// validateHost 检查 ShowDoc 地址，为空时不检查
+func validateHost(field, host string) []error {
+	if host == "" {
+		return nil
+	}
+	if u, err := url.Parse(host); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return []error{fmt.Errorf("%s.host 不是有效的地址: %s", field, host)}
+	}
+	return nil
+}
+
+// ValidateConfig 检查配置文件，输出配置文件中的设置
+func ValidateConfig(fileName string) error {
+	if fileName == "" {
+		return fmt.Errorf("没有找到配置文件 %s", ConfigFile)
+	}
+	cfg, err := LoadConfig(fileName)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		for _, e := range strings.Split(err.Error(), "\n") {
+			log.Error(e)
+		}
+		return fmt.Errorf("配置文件 %s 无效", fileName)
+	}
+	log.Info("dirs=%s", strings.Join(cfg.Dirs, ", "))
+	log.Info("route_dirs=%s", strings.Join(cfg.RouteDirs, ", "))
+	log.Info("exclude=%s", strings.Join(cfg.Exclude, ", "))
+	log.Info("catalog_prefix=%s", cfg.CatalogPrefix)
+	log.Info("include_tags=%s", strings.Join(cfg.IncludeTags, ", "))
+	log.Info("exclude_tags=%s", strings.Join(cfg.ExcludeTags, ", "))
+	log.Info("project.host=%s", cfg.Project.Host)
+	log.Info("project.item_id=%s", cfg.Project.ItemId)
+	for _, project := range cfg.Projects {
+		log.Info("projects[%s].catalogs=%s", project.Name, strings.Join(project.Catalogs, ", "))
+		log.Info("projects[%s].tags=%s", project.Name, strings.Join(project.Tags, ", "))
+		log.Info("projects[%s].item_id=%s", project.Name, project.ItemId)
+	}
+	log.Success("配置文件 %s 有效", fileName)
+	return nil
+}
+
+// rebasePath 将相对于 base 目录的路径转换为当前目录的相对路径，绝对路径不变
+func rebasePath(base, p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	p = filepath.Join(base, p)
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, p); err == nil {
+			return rel
+		}
+	}
+	return p
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/whaios/goshowdoc/log"
+	"github.com/whaios/goshowdoc/parser"
+)
+
+// DefaultIRFile dump 命令默认输出的中间格式文件
+const DefaultIRFile = "goshowdoc.json"
+
+// Dump 解析 Go 源码注释，输出文档的中间格式
+func Dump(opts ParseOptions, output string) error {
+	p, err := parseApiDoc(opts)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		output = DefaultIRFile
+	}
+	if err := os.WriteFile(output, p.IR().Json(), 0644); err != nil {
+		return err
+	}
+	log.Success("导出完成 %s，共 %d 个文档", output, len(p.Docs))
+	return nil
+}
+
+// Load 读取中间格式的文档，更新到 ShowDoc
+//
+// @param targets 更新文档的项目，最后一个为默认项目
+// @param includeTags 只更新有任意一个标签的文档，为空时不限制
+// @param excludeTags 不更新有任意一个标签的文档
+func Load(ctx context.Context, targets []*Target, input string, includeTags, excludeTags []string, updateOpts UpdateOptions) error {
+	if err := checkPruneTags(updateOpts, includeTags, excludeTags); err != nil {
+		return err
+	}
+	if input == "" {
+		input = DefaultIRFile
+	}
+	log.Info("读取中间格式文件 %s", input)
+	ir, err := parser.LoadIR(input)
+	if err != nil {
+		return err
+	}
+	for i, doc := range ir.Docs {
+		log.Info("读取文档(%d) %s", i+1, doc.Name())
+	}
+	docs := parser.FilterTags(ir.Docs, includeTags, excludeTags)
+	if len(docs) != len(ir.Docs) {
+		log.Info("按标签过滤文档，保留 %d 个，去掉 %d 个", len(docs), len(ir.Docs)-len(docs))
+	}
+	return publishTargets(ctx, targets, docs, updateOpts)
+}
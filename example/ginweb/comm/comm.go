@@ -10,3 +10,21 @@ type Page struct {
 	Page     int `json:"page"`      // 第几页
 	PageSize int `json:"page_size"` // 每页显示条数
 }
+
+// Result 通用返回结果
+type Result[T any] struct {
+	HttpCode
+	Data T `json:"data"` // 返回数据
+}
+
+// PageList 分页列表
+type PageList[T any] struct {
+	TotalCount int `json:"total_count"` // 总条数
+	Items      []T `json:"items"`       // 列表项
+}
+
+// KeyValue 键值对
+type KeyValue[K comparable, V any] struct {
+	Key   K `json:"key"`   // 键
+	Value V `json:"value"` // 值
+}
@@ -3,6 +3,8 @@ package book
 import (
 	_ "ginweb/comm"
 	_ "ginweb/model/book"
+
+	"github.com/gin-gonic/gin"
 )
 
 // Handler 书籍管理
@@ -20,11 +22,10 @@ type Handler struct {
 // List 获取书籍列表
 //
 // @description 分页获取书籍列表
-// @url GET {{BASEURL}}/api/v1/book/list
 // @query	page		int	true	""	"第几页"
 // @query	page_size	int	true	""	"每页显示条数"
 // @resp ListRsp{}
-func (h *Handler) List() {
+func (h *Handler) List(c *gin.Context) {
 }
 
 // Detail 获取指定书籍详情
@@ -32,7 +33,7 @@ func (h *Handler) List() {
 // @url GET {{BASEURL}}/api/v1/book/detail/:id
 // @path_var id int true "" "书籍 id"
 // @resp Detail{}
-func (h *Handler) Detail() {
+func (h *Handler) Detail(c *gin.Context) {
 }
 
 // CreateOrUpdate 新建或编辑书籍
@@ -40,7 +41,7 @@ func (h *Handler) Detail() {
 // @catalog 管理
 // @url POST {{BASEURL}}/api/v1/book/edit
 // @param book.Book{}
-func (h *Handler) CreateOrUpdate() {
+func (h *Handler) CreateOrUpdate(c *gin.Context) {
 }
 
 // Delete
@@ -50,5 +51,5 @@ func (h *Handler) CreateOrUpdate() {
 // @url DELETE {{BASEURL}}/api/v1/book/del/:id
 // @path_var id int true "" "书籍 id"
 // @remark 危险操作
-func (h *Handler) Delete() {
+func (h *Handler) Delete(c *gin.Context) {
 }
@@ -4,6 +4,7 @@ import (
 	. "ginweb/comm"               // 测试 . 包
 	"ginweb/model/book"           // 测试正常导入包
 	review1 "ginweb/model/review" // 测试包别名
+	"ginweb/model/tag/v2"         // 测试包名和导入路径不同
 )
 
 // ListRsp 列表返回结果
@@ -33,3 +34,10 @@ type Book struct {
 	book.Book        // 测试同名包+同名结构体
 	Desc      string `json:"desc"` // 介绍
 }
+
+// Summary 测试泛型类型字段
+type Summary struct {
+	Latest  Result[*ListItem]     `json:"latest"`   // 最新书籍
+	Counter KeyValue[string, int] `json:"counter"`  // 书籍数量
+	HotTags []tag.Tag             `json:"hot_tags"` // 热门标签
+}
@@ -0,0 +1,7 @@
+package main
+
+import "ginweb/router"
+
+func main() {
+	_ = router.New().Run(":8080")
+}
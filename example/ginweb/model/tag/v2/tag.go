@@ -0,0 +1,6 @@
+package tag
+
+// Tag 书籍标签，测试包名和导入路径最后一级不同的包
+type Tag struct {
+	Name string `json:"name"` // 标签名
+}
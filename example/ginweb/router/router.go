@@ -0,0 +1,24 @@
+package router
+
+import (
+	"ginweb/handler/book"
+
+	"github.com/gin-gonic/gin"
+)
+
+// New 创建路由
+func New() *gin.Engine {
+	r := gin.Default()
+	api := r.Group("/api/v1")
+	registerBook(api.Group("/book"))
+	return r
+}
+
+// registerBook 注册书籍管理接口，没有 @url 注释的接口会使用这里注册的路由
+func registerBook(rg *gin.RouterGroup) {
+	h := &book.Handler{}
+	rg.GET("/list", h.List)
+	rg.GET("/detail/:id", h.Detail)
+	rg.POST("/edit", h.CreateOrUpdate)
+	rg.DELETE("/del/:id", h.Delete)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/whaios/goshowdoc/export"
+	"github.com/whaios/goshowdoc/log"
+)
+
+// 导出文档的格式
+const (
+	FormatOpenApi  = "openapi"  // OpenAPI 3.1 文档
+	FormatPostman  = "postman"  // Postman Collection v2.1
+	FormatMarkdown = "markdown" // Markdown 文件，每个目录一个文件
+	FormatHtml     = "html"     // 单个 HTML 文件
+)
+
+// ExportOptions 导出文档的参数
+type ExportOptions struct {
+	Format  string // 导出的格式，如：openapi
+	Output  string // 导出的文件，Markdown 格式为导出的目录
+	Title   string // 文档标题，Postman Collection 的名称
+	Version string // 接口版本
+	Server  string // 接口地址前缀，替换 url 中的 {{BASEURL}}，为空时使用 Vars 中的 BASEURL
+
+	Vars map[string]string // 文档中 RunApi 环境变量的值，如：BASEURL
+}
+
+// Export 解析 Go 源码注释，导出为其他格式的文档
+func Export(opts ParseOptions, exportOpts ExportOptions) error {
+	switch exportOpts.Format {
+	case FormatOpenApi, FormatPostman, FormatMarkdown, FormatHtml:
+	default:
+		return fmt.Errorf("不支持的导出格式: %s", exportOpts.Format)
+	}
+	p, err := parseApiDoc(opts)
+	if err != nil {
+		return err
+	}
+
+	if exportOpts.Server == "" {
+		exportOpts.Server = exportOpts.Vars["BASEURL"]
+	}
+
+	var data []byte
+	var output string
+	switch exportOpts.Format {
+	case FormatOpenApi:
+		document := export.OpenApi(p.Docs, p.Schemas, export.OpenApiOptions{
+			Title:   exportOpts.Title,
+			Version: exportOpts.Version,
+			Server:  exportOpts.Server,
+		})
+		data, output = document.Json(), "openapi.json"
+	case FormatPostman:
+		collection := export.Postman(p.Docs, export.PostmanOptions{Name: exportOpts.Title, Variables: exportOpts.Vars})
+		data, output = collection.Json(), "postman_collection.json"
+	case FormatHtml:
+		if data, err = export.Html(p.Docs, export.HtmlOptions{Title: exportOpts.Title}); err != nil {
+			return err
+		}
+		output = "api.html"
+	case FormatMarkdown:
+		dir := exportOpts.Output
+		if dir == "" {
+			dir = "docs"
+		}
+		return writeFiles(dir, export.Markdown(p.Docs, export.MarkdownOptions{Title: exportOpts.Title}))
+	}
+
+	if exportOpts.Output != "" {
+		output = exportOpts.Output
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return err
+	}
+	log.Success("导出完成 %s", output)
+	return nil
+}
+
+// writeFiles 将导出的文件写入目录
+func writeFiles(dir string, files []*export.File) error {
+	for _, file := range files {
+		fileName := filepath.Join(dir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(fileName, file.Content, 0644); err != nil {
+			return err
+		}
+	}
+	log.Success("导出完成 %s，共 %d 个文件", dir, len(files))
+	return nil
+}
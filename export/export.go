@@ -0,0 +1,69 @@
+// Package export 将解析的接口文档导出为其他格式，如：OpenAPI、Postman Collection、Markdown 和 HTML
+package export
+
+import (
+	"path"
+	"strings"
+
+	"github.com/whaios/goshowdoc/parser"
+)
+
+// File 导出的文件
+type File struct {
+	Path    string // 相对于导出目录的路径，使用 / 分隔
+	Content []byte
+}
+
+// docDescription 接口的说明，包含描述和备注
+func docDescription(doc *parser.ApiDoc) string {
+	description := doc.Description
+	if doc.Remark != "" {
+		if description != "" {
+			description += "\n\n"
+		}
+		description += doc.Remark
+	}
+	return description
+}
+
+// catalogGroup 同一目录下的接口文档
+type catalogGroup struct {
+	Catalog string
+	Docs    []*parser.ApiDoc
+}
+
+// groupByCatalog 按目录分组，目录按第一次出现的顺序排列
+func groupByCatalog(docs []*parser.ApiDoc) []*catalogGroup {
+	var groups []*catalogGroup
+	index := make(map[string]*catalogGroup)
+	for _, doc := range docs {
+		group, ok := index[doc.Catalog]
+		if !ok {
+			group = &catalogGroup{Catalog: doc.Catalog}
+			index[doc.Catalog] = group
+			groups = append(groups, group)
+		}
+		group.Docs = append(group.Docs, doc)
+	}
+	return groups
+}
+
+var fileNameReplacer = strings.NewReplacer(`\`, "_", ":", "_", "*", "_", "?", "_", `"`, "_", "<", "_", ">", "_", "|", "_")
+
+// catalogFileName 目录对应的文件路径，多层目录对应多层文件夹。如：测试文档/书籍 => 测试文档/书籍.md
+func catalogFileName(catalog, ext string) string {
+	segments := strings.Split(catalog, "/")
+	for i, segment := range segments {
+		segments[i] = fileNameReplacer.Replace(strings.TrimSpace(segment))
+	}
+	return path.Join(segments...) + ext
+}
+
+func inStrings(s string, list []string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
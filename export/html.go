@@ -0,0 +1,142 @@
+package export
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/whaios/goshowdoc/parser"
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+//go:embed html.tmpl
+var htmlTemplate string
+
+// HtmlOptions 导出 HTML 文档的参数
+type HtmlOptions struct {
+	Title string // 文档标题
+}
+
+// htmlNode 目录树中的目录
+type htmlNode struct {
+	Name     string
+	Children []*htmlNode
+	Docs     []*htmlDoc
+}
+
+// htmlDoc 页面中的一个接口文档
+type htmlDoc struct {
+	Id  string // 页面中的锚点
+	Doc *parser.ApiDoc
+}
+
+// Html 将解析的接口文档转换为单个 HTML 文件，包含目录树、参数表格和高亮的 JSON 示例。
+// 样式写在页面中，不引用外部资源，可以离线查看。
+func Html(docs []*parser.ApiDoc, opts HtmlOptions) ([]byte, error) {
+	tmpl, err := template.New("html").Funcs(template.FuncMap{
+		"upper":      strings.ToUpper,
+		"required":   requiredText,
+		"json":       highlightJson,
+		"deprecated": func(doc *parser.ApiDoc) bool { return doc.Request.ApiStatus == "5" },
+		"isJsonMode": func(doc *parser.ApiDoc) bool { return doc.Request.ParamMode == runapi.ParamModeJson },
+		"section":    func(title string, value interface{}) *htmlSection { return &htmlSection{Title: title, Value: value} },
+	}).Parse(htmlTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &htmlNode{}
+	var all []*htmlDoc
+	for i, doc := range docs {
+		node := root
+		if doc.Catalog != "" {
+			for _, name := range strings.Split(doc.Catalog, "/") {
+				node = node.child(name)
+			}
+		}
+		item := &htmlDoc{Id: fmt.Sprintf("api-%d", i+1), Doc: doc}
+		node.Docs = append(node.Docs, item)
+		all = append(all, item)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, map[string]interface{}{
+		"Title": opts.Title,
+		"Tree":  root,
+		"Docs":  all,
+	})
+	return buf.Bytes(), err
+}
+
+// htmlSection 参数表格或返回示例，在模板中使用
+type htmlSection struct {
+	Title string
+	Value interface{} // 参数列表或 parser.ApiResponse
+}
+
+// child 查找或创建子目录
+func (n *htmlNode) child(name string) *htmlNode {
+	for _, child := range n.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	child := &htmlNode{Name: name}
+	n.Children = append(n.Children, child)
+	return child
+}
+
+// highlightJson 为 JSON 示例添加语法高亮，区分字段名、字符串、数字和 true/false/null
+func highlightJson(s string) template.HTML {
+	var buf strings.Builder
+	span := func(class, text string) {
+		fmt.Fprintf(&buf, `<span class="%s">%s</span>`, class, template.HTMLEscapeString(text))
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(s) {
+				j++
+			}
+			// 后面是冒号的字符串是字段名
+			k := j
+			for k < len(s) && (s[k] == ' ' || s[k] == '\t') {
+				k++
+			}
+			if k < len(s) && s[k] == ':' {
+				span("key", s[i:j])
+			} else {
+				span("string", s[i:j])
+			}
+			i = j
+		case c == '-' || c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(s) && strings.IndexByte("0123456789.eE+-", s[j]) >= 0 {
+				j++
+			}
+			span("number", s[i:j])
+			i = j
+		case strings.HasPrefix(s[i:], "true") || strings.HasPrefix(s[i:], "false") || strings.HasPrefix(s[i:], "null"):
+			j := i + 4
+			if c == 'f' {
+				j++
+			}
+			span("literal", s[i:j])
+			i = j
+		default:
+			buf.WriteString(template.HTMLEscapeString(string(c)))
+			i++
+		}
+	}
+	return template.HTML(buf.String())
+}
@@ -0,0 +1,153 @@
+package export
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/whaios/goshowdoc/parser"
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+// MarkdownIndex Markdown 文档的索引文件，包含所有目录的链接和没有目录的接口
+const MarkdownIndex = "README.md"
+
+// MarkdownOptions 导出 Markdown 文档的参数
+type MarkdownOptions struct {
+	Title string // 文档标题
+}
+
+// Markdown 将解析的接口文档转换为 Markdown 文件，每个目录一个文件，多层目录对应多层文件夹。
+// 索引文件 README.md 包含所有目录的链接，没有目录的接口也写在索引文件中。
+func Markdown(docs []*parser.ApiDoc, opts MarkdownOptions) []*File {
+	var files []*File
+	var index strings.Builder
+	fmt.Fprintf(&index, "# %s\n\n", opts.Title)
+
+	var uncategorized []*parser.ApiDoc
+	for _, group := range groupByCatalog(docs) {
+		if group.Catalog == "" {
+			uncategorized = group.Docs
+			continue
+		}
+		fileName := catalogFileName(group.Catalog, ".md")
+		fmt.Fprintf(&index, "- [%s](%s)\n", group.Catalog, markdownLink(fileName))
+		for _, doc := range group.Docs {
+			fmt.Fprintf(&index, "  - [%s](%s#%s)\n", doc.Title, markdownLink(fileName), markdownAnchor(doc.Title))
+		}
+
+		var buf strings.Builder
+		fmt.Fprintf(&buf, "# %s\n\n", group.Catalog)
+		// 返回索引文件的相对路径
+		fmt.Fprintf(&buf, "[返回目录](%s)\n\n", strings.Repeat("../", strings.Count(fileName, "/"))+MarkdownIndex)
+		for _, doc := range group.Docs {
+			writeMarkdownDoc(&buf, doc)
+		}
+		files = append(files, markdownFile(fileName, buf.String()))
+	}
+	if len(uncategorized) > 0 {
+		index.WriteString("\n")
+	}
+	for _, doc := range uncategorized {
+		writeMarkdownDoc(&index, doc)
+	}
+	return append([]*File{markdownFile(MarkdownIndex, index.String())}, files...)
+}
+
+// markdownFile 去掉文件末尾多余的空行
+func markdownFile(fileName, content string) *File {
+	return &File{Path: fileName, Content: []byte(strings.TrimRight(content, "\n") + "\n")}
+}
+
+// writeMarkdownDoc 输出一个接口的文档，格式和 ShowDoc 的接口文档一致
+func writeMarkdownDoc(buf *strings.Builder, doc *parser.ApiDoc) {
+	fmt.Fprintf(buf, "## %s\n\n", doc.Title)
+	if doc.Request.ApiStatus == "5" {
+		buf.WriteString("> 已废弃\n\n")
+	}
+	if doc.Description != "" {
+		fmt.Fprintf(buf, "%s\n\n", doc.Description)
+	}
+	fmt.Fprintf(buf, "**请求地址**\n\n```\n%s %s\n```\n\n", strings.ToUpper(doc.Request.Method), doc.Request.Url)
+
+	writeMarkdownRequestParams(buf, "请求头", doc.Request.Headers)
+	writeMarkdownRequestParams(buf, "路径参数", doc.Request.PathVariable)
+	writeMarkdownRequestParams(buf, "Query 参数", doc.Request.Query)
+	writeMarkdownRequestParams(buf, fmt.Sprintf("Body 参数（%s）", doc.Request.ParamMode), doc.Request.Params)
+	if doc.Request.ParamMode == runapi.ParamModeJson && doc.Request.ParamJson != "" {
+		fmt.Fprintf(buf, "**请求示例**\n\n```json\n%s\n```\n\n", doc.Request.ParamJson)
+	}
+
+	writeMarkdownResponse(buf, "返回", doc.Response)
+	writeMarkdownResponse(buf, "失败返回", doc.ResponseFail)
+
+	if doc.Remark != "" {
+		fmt.Fprintf(buf, "**备注**\n\n%s\n\n", doc.Remark)
+	}
+}
+
+func writeMarkdownRequestParams(buf *strings.Builder, title string, params []runapi.RequestParam) {
+	if len(params) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "**%s**\n\n", title)
+	buf.WriteString("| 参数名 | 类型 | 必填 | 示例值 | 说明 |\n|:---|:---|:---|:---|:---|\n")
+	for _, param := range params {
+		fmt.Fprintf(buf, "| %s | %s | %s | %s | %s |\n", markdownCell(param.Name), param.Type,
+			requiredText(param.Require), markdownCell(param.Value), markdownCell(param.Remark))
+	}
+	buf.WriteString("\n")
+}
+
+func writeMarkdownResponse(buf *strings.Builder, title string, resp parser.ApiResponse) {
+	if len(resp.Params) > 0 {
+		fmt.Fprintf(buf, "**%s参数**\n\n", title)
+		buf.WriteString("| 参数名 | 类型 | 说明 |\n|:---|:---|:---|\n")
+		for _, param := range resp.Params {
+			fmt.Fprintf(buf, "| %s | %s | %s |\n", markdownCell(param.Name), param.Type, markdownCell(param.Remark))
+		}
+		buf.WriteString("\n")
+	}
+	if resp.Example != "" {
+		fmt.Fprintf(buf, "**%s示例**\n\n```json\n%s\n```\n\n", title, resp.Example)
+	}
+}
+
+func requiredText(require string) string {
+	if require == "1" {
+		return "是"
+	}
+	return "否"
+}
+
+var markdownCellReplacer = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+// markdownCell 表格单元格的内容，转义竖线和换行
+func markdownCell(s string) string {
+	return markdownCellReplacer.Replace(s)
+}
+
+// markdownLink 链接中的路径，转义空格等字符
+func markdownLink(fileName string) string {
+	segments := strings.Split(fileName, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(segment, " ", "%20")
+	}
+	return path.Join(segments...)
+}
+
+// markdownAnchor 标题的锚点，和 GitHub 的规则一致：转为小写，去掉标点，空格替换为 -
+func markdownAnchor(title string) string {
+	var buf strings.Builder
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r == ' ':
+			buf.WriteRune('-')
+		case r == '-' || r == '_' || r > 0x7f && !strings.ContainsRune("，。、；：？！（）【】《》“”‘’", r):
+			buf.WriteRune(r)
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
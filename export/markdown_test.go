@@ -0,0 +1,69 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/whaios/goshowdoc/parser"
+)
+
+func TestMarkdown(t *testing.T) {
+	Convey("测试导出 Markdown 文档", t, func() {
+		p := parser.NewParser()
+		p.RouteDirs = []string{"../example/ginweb/router"}
+		So(p.ParseApiDoc("../example/ginweb/handler"), ShouldBeNil)
+
+		files := Markdown(p.Docs, MarkdownOptions{Title: "书籍"})
+		So(len(files), ShouldEqual, 3)
+		So(files[0].Path, ShouldEqual, MarkdownIndex)
+		So(files[1].Path, ShouldEqual, "测试文档/书籍.md")
+		So(files[2].Path, ShouldEqual, "测试文档/书籍/管理.md")
+
+		index := string(files[0].Content)
+		So(index, ShouldStartWith, "# 书籍\n")
+		So(index, ShouldContainSubstring, "- [测试文档/书籍](测试文档/书籍.md)\n  - [获取书籍列表](测试文档/书籍.md#获取书籍列表)\n")
+
+		books := string(files[1].Content)
+		So(books, ShouldContainSubstring, "[返回目录](../README.md)")
+		So(books, ShouldContainSubstring, "**请求地址**\n\n```\nGET {{BASEURL}}/api/v1/book/list\n```")
+		So(books, ShouldContainSubstring, "| page | int | 是 |  | 第几页 |")
+		So(books, ShouldNotEndWith, "\n\n")
+
+		manage := string(files[2].Content)
+		So(manage, ShouldContainSubstring, "[返回目录](../../README.md)")
+		So(manage, ShouldContainSubstring, "**请求示例**\n\n```json\n")
+	})
+}
+
+func TestMarkdownAnchor(t *testing.T) {
+	Convey("测试 Markdown 标题锚点", t, func() {
+		So(markdownAnchor("Get Book List"), ShouldEqual, "get-book-list")
+		So(markdownAnchor("获取书籍（分页）"), ShouldEqual, "获取书籍分页")
+		So(markdownCell("a|b\nc"), ShouldEqual, `a\|b<br>c`)
+	})
+}
+
+func TestHtml(t *testing.T) {
+	Convey("测试导出 HTML 文档", t, func() {
+		p := parser.NewParser()
+		p.RouteDirs = []string{"../example/ginweb/router"}
+		So(p.ParseApiDoc("../example/ginweb/handler"), ShouldBeNil)
+
+		data, err := Html(p.Docs, HtmlOptions{Title: "书籍"})
+		So(err, ShouldBeNil)
+		html := string(data)
+		So(html, ShouldContainSubstring, "<title>书籍</title>")
+		So(html, ShouldContainSubstring, "<summary>管理</summary>")
+		So(html, ShouldContainSubstring, `<a href="#api-1" title="获取书籍列表">获取书籍列表</a>`)
+		So(html, ShouldContainSubstring, `<section id="api-1">`)
+		So(strings.Count(html, "<section "), ShouldEqual, len(p.Docs))
+	})
+
+	Convey("测试 JSON 语法高亮", t, func() {
+		So(string(highlightJson(`{"a": "<b>", "c": -1.5, "d": null}`)), ShouldEqual,
+			`{<span class="key">&#34;a&#34;</span>: <span class="string">&#34;&lt;b&gt;&#34;</span>, `+
+				`<span class="key">&#34;c&#34;</span>: <span class="number">-1.5</span>, `+
+				`<span class="key">&#34;d&#34;</span>: <span class="literal">null</span>}`)
+	})
+}
@@ -0,0 +1,411 @@
+package export
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/whaios/goshowdoc/log"
+	"github.com/whaios/goshowdoc/parser"
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+// OpenApiVersion 导出的 OpenAPI 文档版本
+const OpenApiVersion = "3.1.0"
+
+// OpenApiOptions 导出 OpenAPI 文档的参数
+type OpenApiOptions struct {
+	Title   string // 文档标题
+	Version string // 接口版本
+	Server  string // 接口地址前缀，对应 url 中的 {{BASEURL}}，如：https://api.example.com
+}
+
+// OpenApiDocument OpenAPI 3.1 文档，参考 https://spec.openapis.org/oas/v3.1.0
+type OpenApiDocument struct {
+	OpenApi    string                                  `json:"openapi"`
+	Info       OpenApiInfo                             `json:"info"`
+	Servers    []OpenApiServer                         `json:"servers,omitempty"`
+	Tags       []OpenApiTag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*OpenApiOperation `json:"paths"`
+	Components OpenApiComponents                       `json:"components"`
+}
+
+type OpenApiInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenApiServer struct {
+	Url string `json:"url"`
+}
+
+// OpenApiTag 接口分组，对应文档目录
+type OpenApiTag struct {
+	Name string `json:"name"`
+}
+
+type OpenApiComponents struct {
+	Schemas         map[string]*parser.Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*OpenApiSecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// OpenApiSecurityScheme 认证方式，由 Authorization 请求头生成
+type OpenApiSecurityScheme struct {
+	Type   string `json:"type"`             // http 或 apiKey
+	Scheme string `json:"scheme,omitempty"` // type=http 时的认证方案，如：bearer
+	Name   string `json:"name,omitempty"`   // type=apiKey 时的参数名
+	In     string `json:"in,omitempty"`     // type=apiKey 时的参数位置
+}
+
+// OpenApiOperation 接口
+type OpenApiOperation struct {
+	Tags        []string                    `json:"tags,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Parameters  []*OpenApiParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenApiRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenApiResponse `json:"responses"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+}
+
+// OpenApiParameter 路径、Query 和 Header 参数
+type OpenApiParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"` // path、query 或 header
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required"`
+	Schema      *parser.Schema `json:"schema"`
+	Example     interface{}    `json:"example,omitempty"`
+}
+
+type OpenApiRequestBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*OpenApiMediaType `json:"content"`
+}
+
+type OpenApiResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenApiMediaType `json:"content,omitempty"`
+}
+
+type OpenApiMediaType struct {
+	Schema  *parser.Schema  `json:"schema"`
+	Example json.RawMessage `json:"example,omitempty"`
+}
+
+// Json 格式化的 JSON 文档
+func (d *OpenApiDocument) Json() []byte {
+	data, _ := json.MarshalIndent(d, "", "  ")
+	return data
+}
+
+// 请求参数模式对应的 Content-Type
+var paramModeContentTypes = map[string]string{
+	runapi.ParamModeJson:       "application/json",
+	runapi.ParamModeUrlEncoded: "application/x-www-form-urlencoded",
+	runapi.ParamModeFormData:   "multipart/form-data",
+}
+
+// OpenApi 将解析的接口文档转换为 OpenAPI 3.1 文档。
+// 结构体参数和返回使用 schemas 中的公共定义，通过注释逐个定义的参数还原为对象的 Schema。
+//
+// @param schemas 文档中引用的结构体类型的 Schema 定义，即 parser.Parser.Schemas
+func OpenApi(docs []*parser.ApiDoc, schemas map[string]*parser.Schema, opts OpenApiOptions) *OpenApiDocument {
+	document := &OpenApiDocument{
+		OpenApi: OpenApiVersion,
+		Info:    OpenApiInfo{Title: opts.Title, Version: opts.Version},
+		Paths:   make(map[string]map[string]*OpenApiOperation),
+	}
+	if opts.Server != "" {
+		document.Servers = []OpenApiServer{{Url: strings.TrimRight(opts.Server, "/")}}
+	}
+
+	securitySchemes := make(map[string]*OpenApiSecurityScheme)
+	for _, doc := range docs {
+		path := openApiPath(doc.Request.Path())
+		method := strings.ToLower(doc.Request.Method)
+		if _, ok := document.Paths[path][method]; ok {
+			log.Warn("忽略重复的接口 %s %s: %s", strings.ToUpper(method), path, doc.Name())
+			continue
+		}
+		if document.Paths[path] == nil {
+			document.Paths[path] = make(map[string]*OpenApiOperation)
+		}
+		document.Paths[path][method] = openApiOperation(doc, securitySchemes)
+
+		if doc.Catalog != "" && !hasTag(document.Tags, doc.Catalog) {
+			document.Tags = append(document.Tags, OpenApiTag{Name: doc.Catalog})
+		}
+	}
+
+	document.Components.Schemas = usedSchemas(document, schemas)
+	if len(securitySchemes) > 0 {
+		document.Components.SecuritySchemes = securitySchemes
+	}
+	return document
+}
+
+func openApiOperation(doc *parser.ApiDoc, securitySchemes map[string]*OpenApiSecurityScheme) *OpenApiOperation {
+	op := &OpenApiOperation{
+		Summary:     doc.Title,
+		Description: docDescription(doc),
+		Responses:   make(map[string]*OpenApiResponse),
+		Deprecated:  doc.Request.ApiStatus == "5",
+	}
+	if doc.Catalog != "" {
+		op.Tags = []string{doc.Catalog}
+	}
+
+	for _, param := range doc.Request.PathVariable {
+		p := openApiParameter(param, "path")
+		// 路径参数必须是必填的
+		p.Required = true
+		op.Parameters = append(op.Parameters, p)
+	}
+	for _, param := range doc.Request.Query {
+		op.Parameters = append(op.Parameters, openApiParameter(param, "query"))
+	}
+	for _, param := range doc.Request.Headers {
+		switch strings.ToLower(param.Name) {
+		case "authorization":
+			// OpenAPI 不使用 Authorization 请求头参数，使用认证方式代替
+			name, scheme := securityScheme(param)
+			securitySchemes[name] = scheme
+			op.Security = append(op.Security, map[string][]string{name: {}})
+		case "accept", "content-type":
+			// OpenAPI 中由 content 定义
+		default:
+			op.Parameters = append(op.Parameters, openApiParameter(param, "header"))
+		}
+	}
+
+	if doc.Request.Method != runapi.MethodGet && doc.Request.Method != runapi.MethodHead {
+		op.RequestBody = openApiRequestBody(doc.Request)
+	}
+
+	op.Responses["200"] = openApiResponse("成功", doc.Response)
+	if doc.ResponseFail.Schema != nil || len(doc.ResponseFail.Params) > 0 || doc.ResponseFail.Example != "" {
+		op.Responses["default"] = openApiResponse("失败", doc.ResponseFail)
+	}
+	return op
+}
+
+func openApiParameter(param runapi.RequestParam, in string) *OpenApiParameter {
+	schema := dataTypeSchema(param.Type)
+	return &OpenApiParameter{
+		Name:        param.Name,
+		In:          in,
+		Description: param.Remark,
+		Required:    param.Require == "1",
+		Schema:      schema,
+		Example:     exampleValue(schema, param.Value),
+	}
+}
+
+// securityScheme 根据 Authorization 请求头的值生成认证方式，如：bearer {{TOKEN}}
+func securityScheme(param runapi.RequestParam) (string, *OpenApiSecurityScheme) {
+	if strings.HasPrefix(strings.ToLower(param.Value), "bearer") {
+		return "bearerAuth", &OpenApiSecurityScheme{Type: "http", Scheme: "bearer"}
+	}
+	return "apiKeyAuth", &OpenApiSecurityScheme{Type: "apiKey", Name: param.Name, In: "header"}
+}
+
+// openApiRequestBody Body 参数，json 模式使用结构体的 Schema，表单模式使用参数中的字段名
+func openApiRequestBody(req parser.ApiRequest) *OpenApiRequestBody {
+	contentType, ok := paramModeContentTypes[req.ParamMode]
+	if !ok {
+		contentType = paramModeContentTypes[runapi.ParamModeUrlEncoded]
+	}
+	media := &OpenApiMediaType{}
+	if req.ParamMode == runapi.ParamModeJson && req.ParamSchema != nil {
+		media.Schema = req.ParamSchema
+	} else if len(req.Params) > 0 {
+		media.Schema = requestParamsSchema(req.Params)
+	} else {
+		return nil
+	}
+	if req.ParamMode == runapi.ParamModeJson {
+		media.Example = rawJson(req.ParamJson)
+	}
+	return &OpenApiRequestBody{
+		Required: true,
+		Content:  map[string]*OpenApiMediaType{contentType: media},
+	}
+}
+
+func openApiResponse(description string, resp parser.ApiResponse) *OpenApiResponse {
+	response := &OpenApiResponse{Description: description}
+	media := &OpenApiMediaType{
+		Schema:  resp.Schema,
+		Example: rawJson(resp.Example),
+	}
+	if media.Schema == nil && len(resp.Params) > 0 {
+		media.Schema = responseParamsSchema(resp.Params)
+	}
+	if media.Schema == nil && media.Example == nil {
+		return response
+	}
+	if media.Schema == nil {
+		media.Schema = &parser.Schema{}
+	}
+	response.Content = map[string]*OpenApiMediaType{"application/json": media}
+	return response
+}
+
+// openApiPath 路径参数使用 {name} 格式，如：/book/:id => /book/{id}
+func openApiPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// dataTypeSchema 文档参数类型对应的 Schema，如：runapi.ParamTypeLong
+func dataTypeSchema(dataType string) *parser.Schema {
+	switch dataType {
+	case runapi.ParamTypeInt:
+		return &parser.Schema{Type: "integer", Format: "int32"}
+	case runapi.ParamTypeLong:
+		return &parser.Schema{Type: "integer", Format: "int64"}
+	case runapi.ParamTypeNumber:
+		return &parser.Schema{Type: "number"}
+	case runapi.ParamTypeBoolean:
+		return &parser.Schema{Type: "boolean"}
+	case runapi.ParamTypeArray:
+		return &parser.Schema{Type: "array", Items: &parser.Schema{Type: "string"}}
+	case runapi.ParamTypeObject:
+		return &parser.Schema{Type: "object"}
+	case runapi.ParamTypeDate:
+		return &parser.Schema{Type: "string", Format: "date"}
+	}
+	return &parser.Schema{Type: "string"}
+}
+
+// exampleValue 参数的示例值，按参数类型转换
+func exampleValue(schema *parser.Schema, value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	switch schema.Type {
+	case "integer", "number":
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	case "boolean":
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+// flatParam 展开的参数，子对象的字段名包含父字段名，如：items.id
+type flatParam struct {
+	Name     string
+	Type     string
+	Required bool
+	Remark   string
+}
+
+func requestParamsSchema(params []runapi.RequestParam) *parser.Schema {
+	flat := make([]flatParam, 0, len(params))
+	for _, param := range params {
+		flat = append(flat, flatParam{Name: param.Name, Type: param.Type, Required: param.Require == "1", Remark: param.Remark})
+	}
+	return flatParamsSchema(flat)
+}
+
+func responseParamsSchema(params []runapi.ResponseParam) *parser.Schema {
+	flat := make([]flatParam, 0, len(params))
+	for _, param := range params {
+		flat = append(flat, flatParam{Name: param.Name, Type: param.Type, Remark: param.Remark})
+	}
+	return flatParamsSchema(flat)
+}
+
+// flatParamsSchema 将展开的参数还原为对象的 Schema，数组字段的子字段作为数组元素的字段。
+// 如：items array、items.id int => {items: [{id: 0}]}
+func flatParamsSchema(params []flatParam) *parser.Schema {
+	root := parser.NewObjectSchema()
+	for _, param := range params {
+		names := strings.Split(param.Name, ".")
+		parent := root
+		for _, name := range names[:len(names)-1] {
+			parent = childObject(parent, name)
+		}
+		schema := dataTypeSchema(param.Type)
+		schema.Description = param.Remark
+		if existing, ok := parent.Properties[names[len(names)-1]]; ok && existing.Type == schema.Type {
+			// 先添加了子字段的父字段
+			existing.Description = param.Remark
+			schema = existing
+		}
+		parent.SetProperty(names[len(names)-1], schema, param.Required)
+	}
+	return root
+}
+
+// childObject 父字段对应的对象，数组字段使用数组元素
+func childObject(parent *parser.Schema, name string) *parser.Schema {
+	property, ok := parent.Properties[name]
+	if !ok {
+		property = parser.NewObjectSchema()
+		parent.SetProperty(name, property, false)
+	}
+	if property.Type == "array" {
+		if property.Items == nil || property.Items.Type != "object" {
+			property.Items = parser.NewObjectSchema()
+		}
+		return property.Items
+	}
+	if property.Properties == nil {
+		property.Type = "object"
+		property.Properties = make(map[string]*parser.Schema)
+	}
+	return property
+}
+
+// usedSchemas 文档中直接或间接引用的 Schema 定义，没有引用时返回 nil
+func usedSchemas(document *OpenApiDocument, schemas map[string]*parser.Schema) map[string]*parser.Schema {
+	var roots []*parser.Schema
+	for _, operations := range document.Paths {
+		for _, op := range operations {
+			if op.RequestBody != nil {
+				for _, media := range op.RequestBody.Content {
+					roots = append(roots, media.Schema)
+				}
+			}
+			for _, resp := range op.Responses {
+				for _, media := range resp.Content {
+					roots = append(roots, media.Schema)
+				}
+			}
+		}
+	}
+	used := parser.UsedSchemas(roots, schemas)
+	if len(used) == 0 {
+		return nil
+	}
+	return used
+}
+
+// rawJson 合法的 JSON 作为示例，否则返回空
+func rawJson(s string) json.RawMessage {
+	if s == "" || !json.Valid([]byte(s)) {
+		return nil
+	}
+	return json.RawMessage(s)
+}
+
+func hasTag(tags []OpenApiTag, name string) bool {
+	for _, tag := range tags {
+		if tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
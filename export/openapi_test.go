@@ -0,0 +1,79 @@
+package export
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/whaios/goshowdoc/parser"
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+func TestOpenApi(t *testing.T) {
+	Convey("测试导出 OpenAPI 文档", t, func() {
+		p := parser.NewParser()
+		p.RouteDirs = []string{"../example/ginweb/router"}
+		So(p.ParseApiDoc("../example/ginweb/handler"), ShouldBeNil)
+
+		document := OpenApi(p.Docs, p.Schemas, OpenApiOptions{Title: "书籍", Version: "1.0.0"})
+		So(document.OpenApi, ShouldEqual, "3.1.0")
+		So(len(document.Paths), ShouldEqual, 4)
+
+		list := document.Paths["/api/v1/book/list"]["get"]
+		So(list, ShouldNotBeNil)
+		So(list.Tags, ShouldResemble, []string{"测试文档/书籍"})
+		So(len(list.Parameters), ShouldEqual, 2)
+		So(list.Parameters[0].Name, ShouldEqual, "page")
+		So(list.Parameters[0].In, ShouldEqual, "query")
+		So(list.Security, ShouldResemble, []map[string][]string{{"bearerAuth": {}}})
+		So(list.RequestBody, ShouldBeNil)
+
+		// 通用的返回结构和接口的返回结构使用 allOf 合并
+		resp := list.Responses["200"].Content["application/json"]
+		So(resp.Schema.AllOf[0].Ref, ShouldEqual, "#/components/schemas/comm.HttpCode")
+		So(resp.Schema.AllOf[1].Properties["data"].Ref, ShouldEqual, "#/components/schemas/book.ListRsp")
+
+		detail := document.Paths["/api/v1/book/detail/{id}"]["get"]
+		So(detail.Parameters[0].In, ShouldEqual, "path")
+		So(detail.Parameters[0].Required, ShouldBeTrue)
+
+		edit := document.Paths["/api/v1/book/edit"]["post"]
+		body := edit.RequestBody.Content["application/json"]
+		So(body.Schema.Ref, ShouldEqual, "#/components/schemas/book.Book")
+		So(document.Components.Schemas["book.Book"].Required, ShouldResemble, []string{"title"})
+
+		// 递归类型引用自己
+		review := document.Components.Schemas["review.Review"]
+		So(review.Properties["recursive_reviews"].Items.Ref, ShouldEqual, "#/components/schemas/review.Review")
+		So(json.Valid(document.Json()), ShouldBeTrue)
+	})
+}
+
+func TestOpenApi_FlatParams(t *testing.T) {
+	Convey("测试通过注释定义的参数还原为对象", t, func() {
+		doc := &parser.ApiDoc{Title: "上传", Request: parser.ApiRequest{
+			Method:    runapi.MethodPost,
+			Url:       "{{BASEURL}}/upload/:dir",
+			ParamMode: runapi.ParamModeFormData,
+			Params: []runapi.RequestParam{
+				runapi.NewRequestParam("name", "string", "true", "", "文件名"),
+				runapi.NewRequestParam("size", "int64", "false", "", "大小"),
+			},
+		}}
+		doc.Response.Params = []runapi.ResponseParam{
+			runapi.NewResponseParam("items", "[]File", "文件"),
+			runapi.NewResponseParam("items.id", "int", "id"),
+			runapi.NewResponseParam("page.total", "int", "总数"),
+		}
+
+		document := OpenApi([]*parser.ApiDoc{doc}, nil, OpenApiOptions{})
+		op := document.Paths["/upload/{dir}"]["post"]
+		So(op, ShouldNotBeNil)
+
+		body, _ := json.Marshal(op.RequestBody.Content["multipart/form-data"].Schema)
+		So(string(body), ShouldEqual, `{"type":"object","properties":{"name":{"type":"string","description":"文件名"},"size":{"type":"integer","format":"int64","description":"大小"}},"required":["name"]}`)
+
+		resp, _ := json.Marshal(op.Responses["200"].Content["application/json"].Schema)
+		So(string(resp), ShouldEqual, `{"type":"object","properties":{"items":{"type":"array","description":"文件","items":{"type":"object","properties":{"id":{"type":"integer","format":"int32","description":"id"}}}},"page":{"type":"object","properties":{"total":{"type":"integer","format":"int32","description":"总数"}}}}}`)
+	})
+}
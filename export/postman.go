@@ -0,0 +1,272 @@
+package export
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/whaios/goshowdoc/parser"
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+// PostmanSchema Postman Collection v2.1 的格式定义
+const PostmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// PostmanOptions 导出 Postman Collection 的参数
+type PostmanOptions struct {
+	Name      string            // Collection 名称
+	Variables map[string]string // Collection 变量的值，如：BASEURL，没有设置的变量由使用者在 Postman 中设置
+}
+
+// PostmanCollection Postman Collection v2.1，参考 https://schema.postman.com/collection/json/v2.1.0/draft-07/docs/index.html
+type PostmanCollection struct {
+	Info     PostmanInfo        `json:"info"`
+	Item     []*PostmanItem     `json:"item"`
+	Variable []*PostmanVariable `json:"variable,omitempty"`
+}
+
+type PostmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+// PostmanItem 文件夹或请求。文件夹对应文档目录，包含子项目；请求对应一个接口文档
+type PostmanItem struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Item        []*PostmanItem     `json:"item,omitempty"`
+	Request     *PostmanRequest    `json:"request,omitempty"`
+	Response    []*PostmanResponse `json:"response,omitempty"`
+}
+
+type PostmanRequest struct {
+	Method      string       `json:"method"`
+	Header      []*PostmanKV `json:"header"`
+	Url         PostmanUrl   `json:"url"`
+	Body        *PostmanBody `json:"body,omitempty"`
+	Description string       `json:"description,omitempty"`
+}
+
+type PostmanUrl struct {
+	Raw      string       `json:"raw"`
+	Protocol string       `json:"protocol,omitempty"`
+	Host     []string     `json:"host,omitempty"`
+	Port     string       `json:"port,omitempty"`
+	Path     []string     `json:"path,omitempty"`
+	Query    []*PostmanKV `json:"query,omitempty"`
+	Variable []*PostmanKV `json:"variable,omitempty"` // 路径参数
+}
+
+// PostmanKV 请求头、Query 参数、路径参数和表单参数
+type PostmanKV struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Type        string `json:"type,omitempty"` // 表单参数的类型，固定值 "text"
+	Description string `json:"description,omitempty"`
+}
+
+type PostmanBody struct {
+	Mode       string              `json:"mode"` // raw、urlencoded 或 formdata
+	Raw        string              `json:"raw,omitempty"`
+	Urlencoded []*PostmanKV        `json:"urlencoded,omitempty"`
+	Formdata   []*PostmanKV        `json:"formdata,omitempty"`
+	Options    *PostmanBodyOptions `json:"options,omitempty"`
+}
+
+type PostmanBodyOptions struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
+}
+
+// PostmanResponse 保存的返回示例
+type PostmanResponse struct {
+	Name            string          `json:"name"`
+	OriginalRequest *PostmanRequest `json:"originalRequest"`
+	Status          string          `json:"status,omitempty"`
+	Code            int             `json:"code,omitempty"`
+	PreviewLanguage string          `json:"_postman_previewlanguage"`
+	Header          []*PostmanKV    `json:"header"`
+	Body            string          `json:"body"`
+}
+
+// PostmanVariable Collection 变量，如：{{BASEURL}}、{{TOKEN}}
+type PostmanVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Json 格式化的 JSON 文档
+func (c *PostmanCollection) Json() []byte {
+	data, _ := json.MarshalIndent(c, "", "  ")
+	return data
+}
+
+// Postman 将解析的接口文档转换为 Postman Collection，按文档目录创建文件夹。
+// 文档中的 {{BASEURL}}、{{TOKEN}} 等 RunApi 环境变量作为 Collection 变量。
+func Postman(docs []*parser.ApiDoc, opts PostmanOptions) *PostmanCollection {
+	collection := &PostmanCollection{
+		Info: PostmanInfo{Name: opts.Name, Schema: PostmanSchema},
+		Item: make([]*PostmanItem, 0),
+	}
+	root := &PostmanItem{}
+	for _, doc := range docs {
+		folder := root
+		if doc.Catalog != "" {
+			for _, name := range strings.Split(doc.Catalog, "/") {
+				folder = folder.folder(name)
+			}
+		}
+		folder.Item = append(folder.Item, postmanItem(doc))
+	}
+	collection.Item = append(collection.Item, root.Item...)
+
+	// 收集文档中使用的变量，没有设置值的变量由使用者在 Postman 中设置
+	data := collection.Json()
+	var names []string
+	for _, matches := range postmanVarPattern.FindAllSubmatch(data, -1) {
+		if name := string(matches[1]); !inStrings(name, names) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		collection.Variable = append(collection.Variable, &PostmanVariable{Key: name, Value: opts.Variables[name]})
+	}
+	return collection
+}
+
+var postmanVarPattern = regexp.MustCompile(`\{\{(\w+)}}`)
+
+// folder 查找或创建子文件夹
+func (item *PostmanItem) folder(name string) *PostmanItem {
+	for _, child := range item.Item {
+		if child.Request == nil && child.Name == name {
+			return child
+		}
+	}
+	child := &PostmanItem{Name: name, Item: make([]*PostmanItem, 0)}
+	item.Item = append(item.Item, child)
+	return child
+}
+
+func postmanItem(doc *parser.ApiDoc) *PostmanItem {
+	request := &PostmanRequest{
+		Method:      strings.ToUpper(doc.Request.Method),
+		Header:      make([]*PostmanKV, 0),
+		Url:         postmanUrl(doc.Request),
+		Body:        postmanBody(doc.Request),
+		Description: docDescription(doc),
+	}
+	for _, param := range doc.Request.Headers {
+		request.Header = append(request.Header, &PostmanKV{Key: param.Name, Value: param.Value, Description: param.Remark})
+	}
+
+	item := &PostmanItem{Name: doc.Title, Request: request}
+	if doc.Response.Example != "" {
+		item.Response = append(item.Response, postmanResponse("成功", request, doc.Response.Example, "OK", 200))
+	}
+	if doc.ResponseFail.Example != "" {
+		// 失败返回的状态码未知
+		item.Response = append(item.Response, postmanResponse("失败", request, doc.ResponseFail.Example, "", 0))
+	}
+	return item
+}
+
+// postmanUrl 请求地址，路径参数使用 :name 格式
+func postmanUrl(req parser.ApiRequest) PostmanUrl {
+	u := PostmanUrl{}
+	base := req.Url
+	if i := strings.Index(base, "?"); i >= 0 {
+		base = base[:i]
+	}
+	if strings.HasPrefix(base, "{{") {
+		if i := strings.Index(base, "}}"); i >= 0 {
+			u.Host = []string{base[:i+2]}
+		}
+	} else if parsed, err := url.Parse(base); err == nil && parsed.Host != "" {
+		u.Protocol = parsed.Scheme
+		u.Host = strings.Split(parsed.Hostname(), ".")
+		u.Port = parsed.Port()
+	}
+
+	path := req.Path()
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if strings.HasPrefix(segment, "*") {
+			segment = ":" + segment[1:]
+		}
+		if segment != "" {
+			u.Path = append(u.Path, segment)
+		}
+	}
+	for _, param := range req.PathVariable {
+		u.Variable = append(u.Variable, &PostmanKV{Key: param.Name, Value: param.Value, Description: param.Remark})
+	}
+
+	var query []string
+	for _, param := range req.Query {
+		u.Query = append(u.Query, &PostmanKV{Key: param.Name, Value: param.Value, Description: param.Remark})
+		query = append(query, param.Name+"="+param.Value)
+	}
+
+	u.Raw = strings.Join(u.Host, ".")
+	if u.Protocol != "" {
+		u.Raw = u.Protocol + "://" + u.Raw
+	}
+	if u.Port != "" {
+		u.Raw += ":" + u.Port
+	}
+	u.Raw += "/" + strings.Join(u.Path, "/")
+	if len(query) > 0 {
+		u.Raw += "?" + strings.Join(query, "&")
+	}
+	return u
+}
+
+// postmanBody Body 参数，json 模式使用 ParamJson 作为请求内容
+func postmanBody(req parser.ApiRequest) *PostmanBody {
+	if req.Method == runapi.MethodGet || req.Method == runapi.MethodHead {
+		return nil
+	}
+	switch req.ParamMode {
+	case runapi.ParamModeJson:
+		if req.ParamJson == "" {
+			return nil
+		}
+		body := &PostmanBody{Mode: "raw", Raw: req.ParamJson, Options: &PostmanBodyOptions{}}
+		body.Options.Raw.Language = "json"
+		return body
+	case runapi.ParamModeFormData:
+		if len(req.Params) == 0 {
+			return nil
+		}
+		return &PostmanBody{Mode: "formdata", Formdata: postmanFormParams(req.Params)}
+	default:
+		if len(req.Params) == 0 {
+			return nil
+		}
+		return &PostmanBody{Mode: "urlencoded", Urlencoded: postmanFormParams(req.Params)}
+	}
+}
+
+func postmanFormParams(params []runapi.RequestParam) []*PostmanKV {
+	kvs := make([]*PostmanKV, 0, len(params))
+	for _, param := range params {
+		kvs = append(kvs, &PostmanKV{Key: param.Name, Value: param.Value, Type: "text", Description: param.Remark})
+	}
+	return kvs
+}
+
+func postmanResponse(name string, request *PostmanRequest, body, status string, code int) *PostmanResponse {
+	return &PostmanResponse{
+		Name:            name,
+		OriginalRequest: request,
+		Status:          status,
+		Code:            code,
+		PreviewLanguage: "json",
+		Header:          []*PostmanKV{{Key: "Content-Type", Value: "application/json"}},
+		Body:            body,
+	}
+}
@@ -0,0 +1,63 @@
+package export
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/whaios/goshowdoc/parser"
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+func TestPostman(t *testing.T) {
+	Convey("测试导出 Postman Collection", t, func() {
+		p := parser.NewParser()
+		p.RouteDirs = []string{"../example/ginweb/router"}
+		So(p.ParseApiDoc("../example/ginweb/handler"), ShouldBeNil)
+
+		collection := Postman(p.Docs, PostmanOptions{Name: "书籍"})
+		So(collection.Info.Schema, ShouldEqual, PostmanSchema)
+		So(collection.Variable, ShouldResemble, []*PostmanVariable{{Key: "BASEURL"}, {Key: "TOKEN"}})
+
+		// 设置了值的变量
+		variables := Postman(p.Docs, PostmanOptions{Variables: map[string]string{"BASEURL": "https://api.example.com"}}).Variable
+		So(variables, ShouldResemble, []*PostmanVariable{{Key: "BASEURL", Value: "https://api.example.com"}, {Key: "TOKEN"}})
+
+		// 按目录创建文件夹：测试文档/书籍/管理
+		So(len(collection.Item), ShouldEqual, 1)
+		books := collection.Item[0].Item[0]
+		So(books.Name, ShouldEqual, "书籍")
+		So(len(books.Item), ShouldEqual, 3)
+		So(books.Item[2].Name, ShouldEqual, "管理")
+
+		list := books.Item[0]
+		So(list.Name, ShouldEqual, "获取书籍列表")
+		So(list.Request.Url.Raw, ShouldEqual, "{{BASEURL}}/api/v1/book/list?page=&page_size=")
+		So(list.Request.Header[0].Value, ShouldEqual, "bearer {{TOKEN}}")
+		So(list.Response[0].Code, ShouldEqual, 200)
+		So(list.Response[0].Body, ShouldEqual, p.Docs[0].Response.Example)
+
+		detail := books.Item[1]
+		So(detail.Request.Url.Path, ShouldResemble, []string{"api", "v1", "book", "detail", ":id"})
+		So(detail.Request.Url.Variable[0].Key, ShouldEqual, "id")
+
+		edit := books.Item[2].Item[0]
+		So(edit.Request.Method, ShouldEqual, "POST")
+		So(edit.Request.Body.Mode, ShouldEqual, "raw")
+		So(edit.Request.Body.Raw, ShouldEqual, p.Docs[2].Request.ParamJson)
+		So(json.Valid(collection.Json()), ShouldBeTrue)
+	})
+}
+
+func TestPostman_Url(t *testing.T) {
+	Convey("测试 Postman 请求地址", t, func() {
+		u := postmanUrl(parser.ApiRequest{Url: "http://localhost:8080/files/*path", Query: []runapi.RequestParam{
+			runapi.NewRequestParam("v", "int", "false", "1", "版本"),
+		}})
+		So(u.Protocol, ShouldEqual, "http")
+		So(u.Host, ShouldResemble, []string{"localhost"})
+		So(u.Port, ShouldEqual, "8080")
+		So(u.Path, ShouldResemble, []string{"files", ":path"})
+		So(u.Raw, ShouldEqual, "http://localhost:8080/files/:path?v=1")
+	})
+}
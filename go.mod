@@ -1,20 +1,19 @@
 module github.com/whaios/goshowdoc
 
-go 1.18
+go 1.22.0
 
 require (
 	github.com/darjun/json-gen v0.0.0-20191009032511-efa84ecdc369
 	github.com/fatih/color v1.13.0
-	github.com/levigross/grequests v0.0.0-20190908174114-253788527a1a
 	github.com/smartystreets/goconvey v1.7.2
 	github.com/tidwall/sjson v1.2.5
 	github.com/urfave/cli/v2 v2.11.2
-	golang.org/x/tools v0.1.12
+	golang.org/x/tools v0.30.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
-	github.com/google/go-querystring v1.1.0 // indirect
 	github.com/gopherjs/gopherjs v0.0.0-20181017120253-0766667cb4d1 // indirect
 	github.com/jtolds/gls v4.20.0+incompatible // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
@@ -25,7 +24,7 @@ require (
 	github.com/tidwall/match v1.1.1 // indirect
 	github.com/tidwall/pretty v1.2.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
-	golang.org/x/mod v0.6.0-dev.0.20220419223038-86c51ed26bb4 // indirect
-	golang.org/x/net v0.0.0-20220812174116-3211cb980234 // indirect
-	golang.org/x/sys v0.0.0-20220811171246-fbc7d0a398ab // indirect
+	golang.org/x/mod v0.23.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
 )
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/urfave/cli/v2"
 	"github.com/whaios/goshowdoc/log"
+	"github.com/whaios/goshowdoc/parser"
 	"github.com/whaios/goshowdoc/runapi"
 )
 
@@ -12,9 +14,45 @@ const (
 	GOSHOWDOC_HOST     = "GOSHOWDOC_HOST"
 	GOSHOWDOC_APIKEY   = "GOSHOWDOC_APIKEY"
 	GOSHOWDOC_APITOKEN = "GOSHOWDOC_APITOKEN"
+	GOSHOWDOC_SSID     = "GOSHOWDOC_SSID"
+	GOSHOWDOC_ITEMID   = "GOSHOWDOC_ITEMID"
+	GOSHOWDOC_CONFIG   = "GOSHOWDOC_CONFIG"
 )
 
-const flagDir = "dir"
+const (
+	flagDir        = "dir"
+	flagRouteDir   = "route-dir"
+	flagExclude    = "exclude"
+	flagCatalog    = "catalog-prefix"
+	flagInclude    = "include-tags"
+	flagExcludeTag = "exclude-tags"
+	flagInferTypes = "infer-types"
+	flagStrictPath = "strict-path-var"
+	flagDialect    = "dialect"
+	flagPrefix     = "prefix"
+	flagStrict     = "strict-prefix"
+
+	flagItemId = "item-id"
+	flagDryRun = "dry-run"
+	flagDiff   = "diff"
+	flagState  = "state"
+	flagLock   = "lock"
+	flagForce  = "force"
+
+	flagWorkers = "workers"
+
+	flagPrune          = "prune"
+	flagPruneMode      = "prune-mode"
+	flagArchiveCatalog = "archive-catalog"
+	flagYes            = "yes"
+
+	flagFormat     = "format"
+	flagOutput     = "output"
+	flagInput      = "input"
+	flagTitle      = "title"
+	flagApiVersion = "api-version"
+	flagServer     = "server"
+)
 
 func main() {
 	cli.HelpFlag = &cli.BoolFlag{
@@ -29,25 +67,44 @@ func main() {
 
 	app.Flags = []cli.Flag{
 		&cli.StringFlag{
-			Name:        "host",
-			Usage:       "ShowDoc 地址。",
-			Value:       runapi.Host,
-			Destination: &runapi.Host,
-			EnvVars:     []string{GOSHOWDOC_HOST},
+			Name:    "config",
+			Usage:   "项目配置文件，默认从当前目录开始逐层向上查找 " + ConfigFile + "。命令行参数和环境变量优先于配置文件。",
+			EnvVars: []string{GOSHOWDOC_CONFIG},
+		},
+		&cli.StringFlag{
+			Name:    "host",
+			Usage:   "ShowDoc 地址。",
+			Value:   runapi.DefaultHost,
+			EnvVars: []string{GOSHOWDOC_HOST},
 		},
 		&cli.StringFlag{
-			Name:        "apikey",
-			Usage:       "ShowDoc 开放 API 认证凭证。",
-			Value:       runapi.ApiKey,
-			Destination: &runapi.ApiKey,
-			EnvVars:     []string{GOSHOWDOC_APIKEY},
+			Name:    "apikey",
+			Usage:   "ShowDoc 开放 API 认证凭证。",
+			EnvVars: []string{GOSHOWDOC_APIKEY},
 		},
 		&cli.StringFlag{
-			Name:        "apitoken",
-			Usage:       "ShowDoc 开放 API 认证凭证。",
-			Value:       runapi.ApiToken,
-			Destination: &runapi.ApiToken,
-			EnvVars:     []string{GOSHOWDOC_APITOKEN},
+			Name:    "apitoken",
+			Usage:   "ShowDoc 开放 API 认证凭证。",
+			EnvVars: []string{GOSHOWDOC_APITOKEN},
+		},
+		&cli.StringFlag{
+			Name:    "ssid",
+			Usage:   "ShowDoc 用户的 SessionId，浏览器登录后从 Cookie 中获取 PHPSESSID。查询项目中已有的文档时使用。",
+			EnvVars: []string{GOSHOWDOC_SSID},
+		},
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "单次请求 ShowDoc 的超时时间。",
+			Value: runapi.DefaultTimeout,
+		},
+		&cli.IntFlag{
+			Name:  "retries",
+			Usage: "请求 ShowDoc 出现网络错误、服务端 5xx 错误或返回 HTML 页面时的重试次数，每次重试的等待时间翻倍。",
+			Value: runapi.DefaultRetries,
+		},
+		&cli.Float64Flag{
+			Name:  "rate-limit",
+			Usage: "每秒最多发送的请求数，0 表示不限制。默认官方线上地址每秒 5 个请求，其他地址不限制。",
 		},
 		&cli.BoolFlag{
 			Name:        "debug",
@@ -61,9 +118,19 @@ func main() {
 			Name:  "flags",
 			Usage: "查询应用全局相关参数。",
 			Action: func(c *cli.Context) error {
-				log.Info("showdoc.host=%s", runapi.Host)
-				log.Info("showdoc.apiKey=%s", runapi.ApiKey)
-				log.Info("showdoc.apiToken=%s", runapi.ApiToken)
+				cfg, err := loadConfig(c)
+				if err != nil {
+					return err
+				}
+				client := newClient(c, cfg)
+				log.Info("config=%s", cfg.FileName())
+				log.Info("showdoc.host=%s", client.Host)
+				log.Info("showdoc.apiKey=%s", client.ApiKey)
+				log.Info("showdoc.apiToken=%s", client.ApiToken)
+				log.Info("showdoc.ssid=%s", client.SSID)
+				log.Info("showdoc.timeout=%s", client.Timeout)
+				log.Info("showdoc.retries=%d", client.Retries)
+				log.Info("showdoc.rateLimit=%g", client.RateLimit)
 				return nil
 			},
 		},
@@ -72,17 +139,111 @@ func main() {
 			Aliases:     []string{"u"},
 			Usage:       "解析 Go 源码中的注释，生成并更新 ShowDoc 文档。",
 			Description: "为了确保能正确解析类型，请在目标项目路径下使用该工具。",
-			Flags: []cli.Flag{
+			Flags:       append(parseFlags(), updateFlags()...),
+			Action: func(c *cli.Context) error {
+				cfg, err := loadConfig(c)
+				if err != nil {
+					return err
+				}
+				return Update(c.Context, newTargets(c, cfg), parseOptions(c, cfg), updateOptions(c, cfg))
+			},
+		},
+		{
+			Name:        "export",
+			Usage:       "解析 Go 源码中的注释，导出为其他格式的文档。",
+			Description: "为了确保能正确解析类型，请在目标项目路径下使用该工具。",
+			Flags: append(parseFlags(),
+				&cli.StringFlag{
+					Name:  flagFormat,
+					Value: FormatOpenApi,
+					Usage: "可选，导出的格式。openapi：OpenAPI 3.1 文档；postman：Postman Collection v2.1；markdown：Markdown 文件，每个目录一个文件；html：单个 HTML 文件，可以离线查看。",
+				},
+				&cli.StringFlag{
+					Name:    flagOutput,
+					Aliases: []string{"o"},
+					Usage:   "可选，导出的文件，默认为当前目录下的 openapi.json、postman_collection.json 或 api.html。markdown 格式为导出的目录，默认为 docs。",
+				},
+				&cli.StringFlag{
+					Name:  flagTitle,
+					Value: "API",
+					Usage: "可选，文档标题，也是 Postman Collection 的名称。",
+				},
+				&cli.StringFlag{
+					Name:  flagApiVersion,
+					Value: "1.0.0",
+					Usage: "可选，接口版本。",
+				},
+				&cli.StringFlag{
+					Name:  flagServer,
+					Usage: "可选，接口地址前缀，替换 url 中的 {{BASEURL}}。如：https://api.example.com",
+				},
+			),
+			Action: func(c *cli.Context) error {
+				cfg, err := loadConfig(c)
+				if err != nil {
+					return err
+				}
+				return Export(parseOptions(c, cfg), ExportOptions{
+					Format:  c.String(flagFormat),
+					Output:  c.String(flagOutput),
+					Title:   c.String(flagTitle),
+					Version: c.String(flagApiVersion),
+					Server:  c.String(flagServer),
+					Vars:    cfg.Vars,
+				})
+			},
+		},
+		{
+			Name:        "dump",
+			Usage:       "解析 Go 源码中的注释，输出文档的中间格式（JSON），用于其他工具读取或修改。",
+			Description: "为了确保能正确解析类型，请在目标项目路径下使用该工具。",
+			Flags: append(parseFlags(),
 				&cli.StringFlag{
-					Name:     flagDir,
-					Value:    "",
-					Usage:    "搜索 Go 源码文件的目录，该目录下必须有 Go 源码文件。",
-					Required: true,
+					Name:    flagOutput,
+					Aliases: []string{"o"},
+					Value:   DefaultIRFile,
+					Usage:   "可选，输出的文件。",
 				},
+			),
+			Action: func(c *cli.Context) error {
+				cfg, err := loadConfig(c)
+				if err != nil {
+					return err
+				}
+				return Dump(parseOptions(c, cfg), c.String(flagOutput))
 			},
+		},
+		{
+			Name:  "load",
+			Usage: "读取 dump 命令输出的中间格式文件，更新 ShowDoc 文档。",
+			Flags: append(append([]cli.Flag{
+				&cli.StringFlag{
+					Name:    flagInput,
+					Aliases: []string{"i"},
+					Value:   DefaultIRFile,
+					Usage:   "可选，读取的中间格式文件。",
+				},
+			}, tagFlags()...), updateFlags()...),
 			Action: func(c *cli.Context) error {
-				Update(c.String(flagDir))
-				return nil
+				cfg, err := loadConfig(c)
+				if err != nil {
+					return err
+				}
+				return Load(c.Context, newTargets(c, cfg), c.String(flagInput),
+					sliceValue(c, flagInclude, cfg.IncludeTags), sliceValue(c, flagExcludeTag, cfg.ExcludeTags), updateOptions(c, cfg))
+			},
+		},
+		{
+			Name:  "config",
+			Usage: "项目配置文件 " + ConfigFile + " 相关命令。",
+			Subcommands: []*cli.Command{
+				{
+					Name:  "validate",
+					Usage: "检查配置文件是否有效。",
+					Action: func(c *cli.Context) error {
+						return ValidateConfig(configFile(c))
+					},
+				},
 			},
 		},
 	}
@@ -91,3 +252,238 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// configFile 命令行参数指定的配置文件，没有指定时从当前目录开始查找，没有找到时为空
+func configFile(c *cli.Context) string {
+	if fileName := c.String("config"); fileName != "" {
+		return fileName
+	}
+	fileName, err := FindConfig(".")
+	if err != nil {
+		log.Warn("查找配置文件失败: %s", err.Error())
+	}
+	return fileName
+}
+
+// loadConfig 读取并检查配置文件，没有配置文件时返回空的配置
+func loadConfig(c *cli.Context) (*Config, error) {
+	cfg, err := LoadConfig(configFile(c))
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("配置文件 %s 无效:\n%v", cfg.FileName(), err)
+	}
+	if cfg.FileName() != "" {
+		log.Debug("使用配置文件 %s", cfg.FileName())
+	}
+	return cfg, nil
+}
+
+// stringValue 命令行参数或环境变量的值，没有设置时使用配置文件中的值
+func stringValue(c *cli.Context, name, configValue string) string {
+	if c.IsSet(name) || configValue == "" {
+		return c.String(name)
+	}
+	return configValue
+}
+
+// sliceValue 命令行参数的值，没有设置时使用配置文件中的值
+func sliceValue(c *cli.Context, name string, configValue []string) []string {
+	if c.IsSet(name) {
+		return c.StringSlice(name)
+	}
+	return configValue
+}
+
+// newClient 使用全局参数创建 ShowDoc 客户端
+func newClient(c *cli.Context, cfg *Config) *runapi.Client {
+	client := runapi.NewClient(
+		stringValue(c, "host", cfg.Project.Host),
+		stringValue(c, "apikey", cfg.Project.ApiKey),
+		stringValue(c, "apitoken", cfg.Project.ApiToken),
+	)
+	setClientOptions(c, client)
+	return client
+}
+
+// newTargets 更新文档的项目，配置文件中 projects 的项目在前，最后一个为全局参数对应的默认项目
+func newTargets(c *cli.Context, cfg *Config) []*Target {
+	defaultClient := newClient(c, cfg)
+	var targets []*Target
+	for _, project := range cfg.Projects {
+		host := project.Host
+		if host == "" {
+			host = defaultClient.Host
+		}
+		client := runapi.NewClient(host, project.ApiKey, project.ApiToken)
+		setClientOptions(c, client)
+		targets = append(targets, &Target{
+			Name:     project.Name,
+			Client:   client,
+			ItemId:   project.ItemId,
+			Catalogs: project.Catalogs,
+			Tags:     project.Tags,
+		})
+	}
+	return append(targets, &Target{Client: defaultClient})
+}
+
+// setClientOptions 使用全局参数设置客户端的请求参数
+func setClientOptions(c *cli.Context, client *runapi.Client) {
+	client.SSID = c.String("ssid")
+	client.Timeout = c.Duration("timeout")
+	client.Retries = c.Int("retries")
+	if c.IsSet("rate-limit") {
+		client.RateLimit = c.Float64("rate-limit")
+	}
+}
+
+// parseFlags 解析 Go 源码注释的命令行参数，update、export 和 dump 命令共用
+func parseFlags() []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  flagDir,
+			Usage: "搜索 Go 源码文件的目录，该目录下必须有 Go 源码文件。可以指定多个，没有设置时使用配置文件中的 dirs。",
+		},
+		&cli.StringSliceFlag{
+			Name:  flagRouteDir,
+			Usage: "可选，路由注册代码所在的目录，没有 @url 注释的接口会使用注册的路由。可以指定多个。",
+		},
+		&cli.StringSliceFlag{
+			Name:  flagExclude,
+			Usage: "可选，不解析注释的文件，glob 格式，可以指定多个。没有斜杆的格式匹配文件名或目录名，如：*_mock.go；有斜杆的格式匹配当前目录的相对路径，如：handler/internal。",
+		},
+		&cli.StringFlag{
+			Name:  flagCatalog,
+			Usage: "可选，所有文档的目录前缀，多层目录用斜杆隔开。",
+		},
+		&cli.BoolFlag{
+			Name:  flagInferTypes,
+			Usage: "可选，没有 @param、@query 和 @resp 注释时，从 gin 处理方法的参数绑定和 c.JSON 调用中推断请求和返回类型。",
+		},
+		&cli.BoolFlag{
+			Name:  flagStrictPath,
+			Usage: "可选，@path_var 注释的参数不在 url 中时停止解析，默认只输出警告。",
+		},
+		&cli.StringFlag{
+			Name:  flagDialect,
+			Value: string(parser.DialectShowDoc),
+			Usage: "可选，注释的语法。showdoc：本工具的注释；swag：兼容 swaggo/swag 的注释（@Summary、@Param、@Success、@Router 等）。",
+		},
+		&cli.StringFlag{
+			Name:  flagPrefix,
+			Usage: "可选，注释标签的命名空间前缀，避免和其他工具的注释冲突。如：showdoc. 对应 @showdoc.url，sd: 对应 @sd:url。",
+		},
+		&cli.BoolFlag{
+			Name:  flagStrict,
+			Usage: "可选，和 --prefix 一起使用，忽略没有前缀的注释标签。",
+		},
+	}, tagFlags()...)
+}
+
+// tagFlags 按标签过滤文档的命令行参数，解析注释的命令和 load 命令共用
+func tagFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  flagInclude,
+			Usage: "可选，只保留有任意一个标签（@tags 注释）的文档，可以指定多个。",
+		},
+		&cli.StringSliceFlag{
+			Name:  flagExcludeTag,
+			Usage: "可选，去掉有任意一个标签（@tags 注释）的文档，可以指定多个，优先于 --include-tags。",
+		},
+	}
+}
+
+// parseOptions 命令行和配置文件中解析 Go 源码注释的参数
+func parseOptions(c *cli.Context, cfg *Config) ParseOptions {
+	return ParseOptions{
+		Dirs:           sliceValue(c, flagDir, cfg.Dirs),
+		RouteDirs:      sliceValue(c, flagRouteDir, cfg.RouteDirs),
+		Exclude:        sliceValue(c, flagExclude, cfg.Exclude),
+		CatalogPrefix:  stringValue(c, flagCatalog, cfg.CatalogPrefix),
+		IncludeTags:    sliceValue(c, flagInclude, cfg.IncludeTags),
+		ExcludeTags:    sliceValue(c, flagExcludeTag, cfg.ExcludeTags),
+		InferTypes:     c.Bool(flagInferTypes),
+		StrictPathVars: c.Bool(flagStrictPath),
+		Dialect:        c.String(flagDialect),
+		Prefix:         c.String(flagPrefix),
+		StrictPrefix:   c.Bool(flagStrict),
+	}
+}
+
+// updateFlags 更新 ShowDoc 文档的命令行参数，update 和 load 命令共用
+func updateFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    flagItemId,
+			Usage:   "可选，ShowDoc 项目 id，用于查询项目中已有的文档，需要同时设置 --ssid 参数，没有设置时和状态文件比较。",
+			EnvVars: []string{GOSHOWDOC_ITEMID},
+		},
+		&cli.BoolFlag{
+			Name:  flagDryRun,
+			Usage: "可选，试运行，只输出将要新建和更新的文档，不修改 ShowDoc 中的文档。设置了 --item-id 和 --ssid 时区分新建、更新和无变化的文档。",
+		},
+		&cli.BoolFlag{
+			Name:  flagDiff,
+			Usage: "可选，更新前查询项目中已有的文档，输出 RunApi 文档内容字段级别的差异，需要设置 --item-id 和 --ssid 参数。",
+		},
+		&cli.StringFlag{
+			Name:  flagState,
+			Usage: "可选，本地状态文件，如：" + DefaultStateFile + "。记录已经上传的文档内容的哈希值，跳过内容没有变化的文档；在 ShowDoc 中删除了页面后需要添加 --force 参数重新上传。没有设置时不使用状态文件，上传所有文档。",
+		},
+		&cli.StringFlag{
+			Name:  flagLock,
+			Value: DefaultLockFile,
+			Usage: "可选，锁文件，记录文档 id 对应的 ShowDoc 页面，修改文档的标题或目录后仍然更新原来的页面，建议和源码一起提交。设置为空时不使用锁文件。",
+		},
+		&cli.BoolFlag{
+			Name:  flagForce,
+			Usage: "可选，上传所有文档，不跳过内容没有变化的文档。",
+		},
+		&cli.IntFlag{
+			Name:    flagWorkers,
+			Aliases: []string{"j"},
+			Value:   4,
+			Usage:   "可选，同时上传的文档数量。一个文档上传失败时继续上传其他文档，最后输出失败的文档。",
+		},
+		&cli.BoolFlag{
+			Name:  flagPrune,
+			Usage: "可选，清理 ShowDoc 中本次没有生成的文档，范围为本次生成的文档所在的目录和状态文件中记录的文档，需要设置 --item-id 和 --ssid 参数。",
+		},
+		&cli.StringFlag{
+			Name:  flagPruneMode,
+			Value: PruneArchive,
+			Usage: "可选，清理文档的方式。archive：移动到归档目录；delete：删除。",
+		},
+		&cli.StringFlag{
+			Name:  flagArchiveCatalog,
+			Value: DefaultArchiveCatalog,
+			Usage: "可选，归档目录，多级目录用 / 隔开，目录不存在时会自动创建。",
+		},
+		&cli.BoolFlag{
+			Name:    flagYes,
+			Aliases: []string{"y"},
+			Usage:   "可选，清理文档时不需要确认，用于自动化脚本。",
+		},
+	}
+}
+
+// updateOptions 命令行和配置文件中更新 ShowDoc 文档的参数
+func updateOptions(c *cli.Context, cfg *Config) UpdateOptions {
+	return UpdateOptions{
+		ItemId:    stringValue(c, flagItemId, cfg.Project.ItemId),
+		DryRun:    c.Bool(flagDryRun),
+		Diff:      c.Bool(flagDiff),
+		StateFile: c.String(flagState),
+		LockFile:  c.String(flagLock),
+		Force:     c.Bool(flagForce),
+		Workers:   c.Int(flagWorkers),
+
+		Prune:          c.Bool(flagPrune),
+		PruneMode:      c.String(flagPruneMode),
+		ArchiveCatalog: c.String(flagArchiveCatalog),
+		Yes:            c.Bool(flagYes),
+	}
+}
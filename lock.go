@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+// DefaultLockFile 默认的锁文件
+const DefaultLockFile = "goshowdoc.lock"
+
+// LockVersion 锁文件的版本
+const LockVersion = 1
+
+// Lock 锁文件，记录文档 id 对应的 ShowDoc 页面，修改文档的标题或目录后仍然更新原来的页面。
+// 锁文件应该和源码一起提交，不同的 ShowDoc 地址和项目分开记录，项目标识和状态文件一致。
+type Lock struct {
+	Version  int                             `json:"version"`
+	Projects map[string]map[string]*LockPage `json:"projects"` // key=项目标识，值为文档 id 对应的页面
+
+	fileName string
+	project  string
+	changed  bool
+}
+
+// LockPage 文档 id 对应的 ShowDoc 页面
+type LockPage struct {
+	PageId string `json:"page_id"`
+	Name   string `json:"name"` // 上次更新时的目录和标题，如：一层/二层/标题
+}
+
+// LoadLock 读取锁文件，文件不存在时返回空的锁文件
+//
+// @param fileName 为空时不读取也不保存锁文件
+func LoadLock(fileName string, client *runapi.Client) (*Lock, error) {
+	lock := &Lock{
+		Version:  LockVersion,
+		Projects: make(map[string]map[string]*LockPage),
+		fileName: fileName,
+		project:  projectKey(client),
+	}
+	if fileName == "" {
+		return lock, nil
+	}
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("解析锁文件 %s 出错: %v", fileName, err)
+	}
+	if lock.Version > LockVersion {
+		return nil, fmt.Errorf("不支持的锁文件版本 %d，请升级 goshowdoc", lock.Version)
+	}
+	if lock.Projects == nil {
+		lock.Projects = make(map[string]map[string]*LockPage)
+	}
+	return lock, nil
+}
+
+// Enabled 是否使用锁文件
+func (l *Lock) Enabled() bool {
+	return l.fileName != ""
+}
+
+// Page 当前项目中文档 id 对应的页面，没有记录时返回 nil
+func (l *Lock) Page(id string) *LockPage {
+	if id == "" {
+		return nil
+	}
+	return l.Projects[l.project][id]
+}
+
+// SetPage 记录当前项目中文档 id 对应的页面
+func (l *Lock) SetPage(id, pageId, name string) {
+	if id == "" || pageId == "" {
+		return
+	}
+	if page := l.Page(id); page != nil && page.PageId == pageId && page.Name == name {
+		return
+	}
+	pages := l.Projects[l.project]
+	if pages == nil {
+		pages = make(map[string]*LockPage)
+		l.Projects[l.project] = pages
+	}
+	pages[id] = &LockPage{PageId: pageId, Name: name}
+	l.changed = true
+}
+
+// Save 保存锁文件，没有修改时不写入文件
+func (l *Lock) Save() error {
+	if !l.Enabled() || !l.changed {
+		return nil
+	}
+	data, _ := json.MarshalIndent(l, "", "  ")
+	return os.WriteFile(l.fileName, data, 0644)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+func TestLock(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), DefaultLockFile)
+	client := runapi.NewClient("http://showdoc.test", "key", "token")
+	another := runapi.NewClient("http://showdoc.test", "another", "token")
+
+	Convey("测试读写锁文件", t, func() {
+		lock, err := LoadLock(fileName, client)
+		So(err, ShouldBeNil)
+		So(lock.Enabled(), ShouldBeTrue)
+		So(lock.Page("book.List"), ShouldBeNil)
+
+		lock.SetPage("book.List", "1", "书籍/列表")
+		lock.SetPage("", "2", "书籍/详情")           // 没有文档 id
+		lock.SetPage("book.Detail", "", "书籍/详情") // 没有页面 id
+		So(lock.Save(), ShouldBeNil)
+
+		lock, err = LoadLock(fileName, client)
+		So(err, ShouldBeNil)
+		So(lock.Page("book.List"), ShouldResemble, &LockPage{PageId: "1", Name: "书籍/列表"})
+		So(lock.Page("book.Detail"), ShouldBeNil)
+		So(lock.Page(""), ShouldBeNil)
+
+		// 不同项目分开记录
+		lock, err = LoadLock(fileName, another)
+		So(err, ShouldBeNil)
+		So(lock.Page("book.List"), ShouldBeNil)
+	})
+
+	Convey("测试没有修改时不写入锁文件", t, func() {
+		So(os.Remove(fileName), ShouldBeNil)
+		lock, err := LoadLock(fileName, client)
+		So(err, ShouldBeNil)
+		So(lock.Save(), ShouldBeNil)
+		_, err = os.Stat(fileName)
+		So(os.IsNotExist(err), ShouldBeTrue)
+
+		lock.SetPage("book.List", "1", "书籍/列表")
+		So(lock.Save(), ShouldBeNil)
+		lock, err = LoadLock(fileName, client)
+		So(err, ShouldBeNil)
+
+		So(os.Remove(fileName), ShouldBeNil)
+		lock.SetPage("book.List", "1", "书籍/列表") // 和记录相同
+		So(lock.Save(), ShouldBeNil)
+		_, err = os.Stat(fileName)
+		So(os.IsNotExist(err), ShouldBeTrue)
+	})
+
+	Convey("测试不支持的锁文件版本", t, func() {
+		So(os.WriteFile(fileName, []byte(`{"version":2,"projects":{}}`), 0644), ShouldBeNil)
+		_, err := LoadLock(fileName, client)
+		So(err, ShouldNotBeNil)
+
+		So(os.WriteFile(fileName, []byte(`{`), 0644), ShouldBeNil)
+		_, err = LoadLock(fileName, client)
+		So(err, ShouldNotBeNil)
+	})
+}
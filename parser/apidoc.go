@@ -9,15 +9,24 @@ import (
 	"strings"
 
 	"github.com/tidwall/sjson"
+	"github.com/whaios/goshowdoc/log"
 	"github.com/whaios/goshowdoc/runapi"
 )
 
+// BaseUrlVar 推断的接口 url 使用的 RunApi 环境变量
+const BaseUrlVar = "{{BASEURL}}"
+
 func newApiDoc(parser *Parser, astFile *ast.File, generalDoc *ApiDoc) *ApiDoc {
 	doc := &ApiDoc{
 		parser:  parser,
 		astFile: astFile,
 		Order:   "99",
 	}
+	if parser != nil {
+		doc.dialect = parser.Dialect
+		doc.prefix = NormalizePrefix(parser.AnnotationPrefix)
+		doc.strictPrefix = parser.StrictPrefix
+	}
 	doc.Request.Headers = make([]runapi.RequestParam, 0)
 	doc.Request.PathVariable = make([]runapi.RequestParam, 0)
 	doc.Request.Query = make([]runapi.RequestParam, 0)
@@ -27,6 +36,7 @@ func newApiDoc(parser *Parser, astFile *ast.File, generalDoc *ApiDoc) *ApiDoc {
 	if generalDoc != nil {
 		doc.Catalog = generalDoc.Catalog
 		doc.Remark = generalDoc.Remark
+		doc.Tags = append(doc.Tags, generalDoc.Tags...)
 		for _, header := range generalDoc.Request.Headers {
 			doc.Request.Headers = append(doc.Request.Headers, header)
 		}
@@ -34,41 +44,76 @@ func newApiDoc(parser *Parser, astFile *ast.File, generalDoc *ApiDoc) *ApiDoc {
 			doc.Response.Params = append(doc.Response.Params, param)
 		}
 		doc.Response.Example = generalDoc.Response.Example
+		doc.Response.Schema = generalDoc.Response.Schema
 	}
 	return doc
 }
 
+// NormalizePrefix 统一注释标签的命名空间前缀，去掉开头的 @ 并转为小写。如：@ShowDoc. => showdoc.
+func NormalizePrefix(prefix string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(prefix), "@"))
+}
+
 // ApiDoc API 接口文档
 type ApiDoc struct {
-	parser  *Parser
-	astFile *ast.File
-
-	Title       string
-	Catalog     string // 例如 “一层/二层/三层”
-	Description string
-	Remark      string
-	Order       string // 文档排序，默认 99
+	parser    *Parser
+	astFile   *ast.File
+	route     *Route          // 路由注册代码中注册的接口
+	annotated map[string]bool // 已经使用的注释，如：@param
+	dialect   Dialect         // 注释的语法
+
+	prefix       string // 注释标签的命名空间前缀，如：showdoc.
+	strictPrefix bool   // 忽略没有前缀的注释标签
+
+	Id          string `json:"id"` // 文档的唯一标识，标题和目录修改后仍然更新原来的文档。默认为包名+方法名
+	Title       string `json:"title"`
+	Catalog     string `json:"catalog"` // 例如 “一层/二层/三层”
+	Description string `json:"description"`
+	Remark      string `json:"remark"`
+	Order       string `json:"order"` // 文档排序，默认 99
+
+	Tags []string `json:"tags,omitempty"` // 文档的标签，用于过滤文档和选择更新的项目，如：public、admin
+
+	Source       ApiSource   `json:"source"`
+	Request      ApiRequest  `json:"request"`
+	Response     ApiResponse `json:"response"`
+	ResponseFail ApiResponse `json:"response_fail"`
+}
 
-	Request      ApiRequest
-	Response     ApiResponse
-	ResponseFail ApiResponse
+// ApiSource 文档注释所在的源码位置
+type ApiSource struct {
+	File    string `json:"file"`    // 源码文件，当前目录的相对路径，使用 / 分隔
+	Line    int    `json:"line"`    // 方法声明所在的行
+	Package string `json:"package"` // 完整包名，如：github.com/whaios/goshowdoc/example/ginweb/handler
+	Func    string `json:"func"`    // 方法名称，带接收者类型，如：Handler.List
 }
 
 type ApiRequest struct {
-	Method       string
-	Url          string
-	ApiStatus    string // 接口状态
-	Headers      []runapi.RequestParam
-	PathVariable []runapi.RequestParam // 路径参数
-	Query        []runapi.RequestParam // GET 请求建议仅用 Query 参数
-	ParamMode    string                // 参数类型：urlencoded formdata json
-	Params       []runapi.RequestParam
-	ParamJson    string
+	Method       string                `json:"method"`
+	Url          string                `json:"url"`
+	ApiStatus    string                `json:"api_status"` // 接口状态
+	Headers      []runapi.RequestParam `json:"headers"`
+	PathVariable []runapi.RequestParam `json:"path_variable"` // 路径参数
+	Query        []runapi.RequestParam `json:"query"`         // GET 请求建议仅用 Query 参数
+	ParamMode    string                `json:"param_mode"`    // 参数类型：urlencoded formdata json
+	Params       []runapi.RequestParam `json:"params"`
+	ParamJson    string                `json:"param_json"`
+	ParamSchema  *Schema               `json:"param_schema,omitempty"` // 结构体请求参数的 Schema，没有使用结构体时为空
 }
 
 type ApiResponse struct {
-	Example string
-	Params  []runapi.ResponseParam
+	Example string                 `json:"example"`
+	Params  []runapi.ResponseParam `json:"params"`
+	Schema  *Schema                `json:"schema,omitempty"` // 结构体返回参数的 Schema，没有使用结构体时为空
+}
+
+// Path 接口地址中的路径，不包含环境变量、域名和 Query 参数，路径参数统一为 :name 格式。如：/api/v1/book/:id
+func (p *ApiRequest) Path() string {
+	path := normalizePath(urlPath(p.Url))
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
 }
 
 // ParseComment 解析单行注释
@@ -83,12 +128,28 @@ func (p *ApiDoc) ParseComment(funcName, comment string) error {
 	attribute := strings.ToLower(strings.Fields(commentLine)[0])
 	lineRemainder := strings.TrimSpace(commentLine[len(attribute):])
 
+	if p.annotated == nil {
+		p.annotated = make(map[string]bool)
+	}
+	if p.dialect == DialectSwag {
+		return p.parseSwagComment(funcName, attribute, lineRemainder)
+	}
+	attribute, ok := p.trimPrefix(attribute)
+	if !ok {
+		return nil
+	}
+	p.annotated[attribute] = true
+
 	var err error
 	switch attribute {
 	case funcName, "@title":
 		p.Title = lineRemainder
+	case "@id":
+		p.Id = lineRemainder
 	case "@catalog":
 		p.parseCatalogComment(lineRemainder)
+	case "@tags":
+		p.parseTagsComment(lineRemainder)
 	case "@desc", "@description":
 		p.parseDescriptionComment(lineRemainder)
 	case "@url":
@@ -115,6 +176,18 @@ func (p *ApiDoc) ParseComment(funcName, comment string) error {
 	return err
 }
 
+// trimPrefix 去掉注释标签的命名空间前缀，如：@showdoc.url => @url
+// 严格模式下没有前缀的注释标签会被忽略。
+func (p *ApiDoc) trimPrefix(attribute string) (string, bool) {
+	if p.prefix == "" || !strings.HasPrefix(attribute, "@") {
+		return attribute, true
+	}
+	if rest := strings.TrimPrefix(attribute, "@"+p.prefix); rest != attribute && rest != "" {
+		return "@" + rest, true
+	}
+	return attribute, !p.strictPrefix
+}
+
 func (p *ApiDoc) parseCatalogComment(commentLine string) {
 	if !strings.HasPrefix(commentLine, "/") {
 		commentLine = "/" + commentLine
@@ -123,6 +196,56 @@ func (p *ApiDoc) parseCatalogComment(commentLine string) {
 	p.Catalog = strings.Trim(p.Catalog, "/")
 }
 
+// parseTagsComment 解析标签，多个标签用逗号隔开，可以有多行。如：public, admin
+func (p *ApiDoc) parseTagsComment(commentLine string) {
+	for _, tag := range strings.Split(commentLine, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" && !p.HasTag(tag) {
+			p.Tags = append(p.Tags, tag)
+		}
+	}
+}
+
+// HasTag 文档是否有指定的标签，不区分大小写
+func (p *ApiDoc) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAnyTag 文档是否有任意一个指定的标签
+func (p *ApiDoc) HasAnyTag(tags []string) bool {
+	for _, tag := range tags {
+		if p.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterTags 按标签过滤文档，排除的标签优先
+//
+// @param include 为空时不限制，否则只保留有任意一个标签的文档
+// @param exclude 去掉有任意一个标签的文档
+func FilterTags(docs []*ApiDoc, include, exclude []string) []*ApiDoc {
+	if len(include) == 0 && len(exclude) == 0 {
+		return docs
+	}
+	result := make([]*ApiDoc, 0, len(docs))
+	for _, doc := range docs {
+		if len(include) > 0 && !doc.HasAnyTag(include) {
+			continue
+		}
+		if doc.HasAnyTag(exclude) {
+			continue
+		}
+		result = append(result, doc)
+	}
+	return result
+}
+
 // parseDescriptionComment 解析多行描述
 func (p *ApiDoc) parseDescriptionComment(commentLine string) {
 	if p.Description != "" {
@@ -145,15 +268,62 @@ func (p *ApiDoc) parseUrlComment(commentLine string) error {
 	if len(fields) != 2 {
 		return fmt.Errorf("无法解析 url 注释 \"%s\"", commentLine)
 	}
-	p.Request.Method = strings.ToLower(fields[0]) // 和runapi保持一致使用小写
-	p.Request.Url = fields[1]
+	method := strings.ToLower(fields[0]) // 和runapi保持一致使用小写
+	if p.route != nil && !isSameRoute(p.route, method, fields[1]) {
+		log.Warn("@url 注释 \"%s\" 和注册的路由 \"%s %s\" 不一致，使用注释中的 url", commentLine, strings.ToUpper(p.route.Method), p.route.Path)
+	}
+	p.setUrl(method, fields[1])
+	return nil
+}
+
+// setRoute 使用注册的路由作为接口 url
+func (p *ApiDoc) setRoute(route *Route) {
+	p.route = route
+	p.setUrl(route.Method, BaseUrlVar+route.Path)
+}
+
+// checkPathVariables 检查路径参数和 url 中的参数是否一致，按 url 中的顺序排列路径参数。
+// url 中没有通过 @path_var 注释说明的参数会自动添加，类型为 string。
+//
+// @return unknown 不在 url 中的路径参数
+func (p *ApiDoc) checkPathVariables() (unknown []string) {
+	names := pathVariables(urlPath(p.Request.Url))
+	params := make([]runapi.RequestParam, 0, len(names))
+	for _, name := range names {
+		param, ok := p.findPathVariable(name)
+		if !ok {
+			param = runapi.NewRequestParam(name, "string", "true", "", "")
+		}
+		params = append(params, param)
+	}
+	for _, param := range p.Request.PathVariable {
+		if !inStrings(param.Name, names) {
+			unknown = append(unknown, param.Name)
+			params = append(params, param)
+		}
+	}
+	p.Request.PathVariable = params
+	return unknown
+}
+
+func (p *ApiDoc) findPathVariable(name string) (runapi.RequestParam, bool) {
+	for _, param := range p.Request.PathVariable {
+		if param.Name == name {
+			return param, true
+		}
+	}
+	return runapi.RequestParam{}, false
+}
+
+func (p *ApiDoc) setUrl(method, url string) {
+	p.Request.Method = method
+	p.Request.Url = url
 	switch p.Request.Method {
 	case runapi.MethodPost:
 		p.Request.ParamMode = runapi.ParamModeJson
 	default:
 		p.Request.ParamMode = runapi.ParamModeUrlEncoded
 	}
-	return nil
 }
 
 // parseApiStatusComment 解析接口状态
@@ -164,11 +334,21 @@ func (p *ApiDoc) parseApiStatusComment(commentLine string) error {
 
 var reqParamPattern = regexp.MustCompile(`(\S+)[\s]+([\w]+)[\s]+([\w]+)[\s]+"([^"]*)"[\s]+"([^"]*)"`)
 
-// parseHeaderComment 解析Header。
+// parseHeaderComment 解析Header。支持结构体，使用字段的 header 标签作为参数名
 //
 // 如：	page		int		true	"1"		"第几页"
 //		[字段名]		[类型]	[必填]	[值]	[备注]
 func (p *ApiDoc) parseHeaderComment(commentLine string) error {
+	if strings.HasSuffix(commentLine, "{}") {
+		obj, err := p.parseObjectComment(commentLine, HeaderTagKeys)
+		if err != nil || obj == nil {
+			return err
+		}
+		params, _ := requestParamsOf(obj, runapi.NewHeaderParam)
+		p.Request.Headers = append(p.Request.Headers, params...)
+		return nil
+	}
+
 	matches := reqParamPattern.FindStringSubmatch(commentLine)
 	if len(matches) != 6 {
 		return fmt.Errorf("无法解析 header 注释 \"%s\"\n不符合格式 [字段名] [类型] [必填] [\"值\"] [\"备注\"]", commentLine)
@@ -179,8 +359,17 @@ func (p *ApiDoc) parseHeaderComment(commentLine string) error {
 	return nil
 }
 
-// parsePathVarComment 解析路径参数
+// parsePathVarComment 解析路径参数。支持结构体，使用字段的 uri 标签作为参数名
 func (p *ApiDoc) parsePathVarComment(commentLine string) error {
+	if strings.HasSuffix(commentLine, "{}") {
+		params, _, err := p.parseRequestParam(commentLine, PathTagKeys)
+		if err != nil {
+			return err
+		}
+		p.Request.PathVariable = append(p.Request.PathVariable, params...)
+		return nil
+	}
+
 	matches := reqParamPattern.FindStringSubmatch(commentLine)
 	if len(matches) != 6 {
 		return fmt.Errorf("无法解析 path_var 注释 \"%s\"\n不符合格式 [字段名] [类型] [必填] [\"值\"] [\"备注\"]", commentLine)
@@ -191,9 +380,9 @@ func (p *ApiDoc) parsePathVarComment(commentLine string) error {
 	return nil
 }
 
-// parseQueryComment 解析Query参数（GET请求建议仅用Query参数）
+// parseQueryComment 解析Query参数（GET请求建议仅用Query参数）。结构体使用字段的 form 标签作为参数名
 func (p *ApiDoc) parseQueryComment(commentLine string) error {
-	params, _, err := p.parseRequestParam(commentLine)
+	params, _, err := p.parseRequestParam(commentLine, QueryTagKeys)
 	if err != nil {
 		return err
 	}
@@ -207,7 +396,7 @@ func (p *ApiDoc) parseParamModeComment(commentLine string) error {
 	case runapi.ParamModeUrlEncoded:
 	case runapi.ParamModeFormData, runapi.ParamModeJson:
 		if p.Request.Method == runapi.MethodGet {
-			fmt.Errorf("GET 请求只支持 %s 模式", runapi.ParamModeUrlEncoded)
+			return fmt.Errorf("GET 请求只支持 %s 模式", runapi.ParamModeUrlEncoded)
 		}
 	default:
 		return fmt.Errorf("不支持 %s 请求参数模式", commentLine)
@@ -218,10 +407,17 @@ func (p *ApiDoc) parseParamModeComment(commentLine string) error {
 
 // parseParamComment 解析Body参数
 func (p *ApiDoc) parseParamComment(commentLine string) error {
-	params, paramJson, err := p.parseRequestParam(commentLine)
+	params, paramJson, err := p.parseRequestParam(commentLine, nil)
 	if err != nil {
 		return err
 	}
+	p.addParams(params, paramJson)
+	p.addParamSchema(p.schemaOfComment(commentLine))
+	return nil
+}
+
+// addParams 添加请求参数，GET 请求的参数作为 Query 参数
+func (p *ApiDoc) addParams(params []runapi.RequestParam, paramJson string) {
 	if p.Request.Method == runapi.MethodGet {
 		p.Request.Query = append(p.Request.Query, params...)
 	} else {
@@ -230,14 +426,27 @@ func (p *ApiDoc) parseParamComment(commentLine string) error {
 	if p.Request.ParamMode == runapi.ParamModeJson {
 		p.Request.ParamJson = paramJson
 	}
-	return nil
+}
+
+// addParamSchema 添加结构体请求参数的 Schema，GET 请求没有 Body 参数，多个结构体参数使用 allOf 合并
+func (p *ApiDoc) addParamSchema(schema *Schema) {
+	if schema == nil || p.Request.Method == runapi.MethodGet {
+		return
+	}
+	if p.Request.ParamSchema == nil {
+		p.Request.ParamSchema = schema
+	} else {
+		p.Request.ParamSchema = &Schema{AllOf: []*Schema{p.Request.ParamSchema, schema}}
+	}
 }
 
 // parseParamComment 解析请求参数
 //
 // 如：	page		int		true	"1"		"第几页"
 //		[字段名]		[类型]	[必填]	[值]	[备注]
-func (p *ApiDoc) parseRequestParam(commentLine string) (params []runapi.RequestParam, paramJson string, err error) {
+//
+// @param tagKeys 结构体参数的字段名使用的标签
+func (p *ApiDoc) parseRequestParam(commentLine string, tagKeys []string) (params []runapi.RequestParam, paramJson string, err error) {
 	if !strings.HasSuffix(commentLine, "{}") {
 		matches := reqParamPattern.FindStringSubmatch(commentLine)
 		if len(matches) != 6 {
@@ -250,16 +459,40 @@ func (p *ApiDoc) parseRequestParam(commentLine string) (params []runapi.RequestP
 		return
 	}
 
-	// 解析对象
+	obj, err := p.parseObjectComment(commentLine, tagKeys)
+	if err != nil || obj == nil {
+		return
+	}
+	params, paramJson = requestParamsOf(obj, runapi.NewRequestParam)
+	return
+}
+
+// parseObjectComment 解析结构体参数，如：ListReq{}
+func (p *ApiDoc) parseObjectComment(commentLine string, tagKeys []string) (*Object, error) {
 	refType := strings.TrimRight(commentLine, "{}")
 	if refType == "" || p.parser == nil {
-		return
+		return nil, nil
 	}
-	obj, err := p.parser.ParseObject(refType, p.astFile)
-	if err != nil || obj == nil {
-		return
+	return p.parser.ParseObject(refType, p.astFile, tagKeys...)
+}
+
+// schemaOfComment 结构体参数的 Schema，如：ListReq{}。不是结构体参数时返回空
+func (p *ApiDoc) schemaOfComment(commentLine string) *Schema {
+	refType := strings.TrimRight(commentLine, "{}")
+	if !strings.HasSuffix(commentLine, "{}") || refType == "" || p.parser == nil {
+		return nil
+	}
+	t := p.parser.packages.FindType(refType, p.astFile)
+	if t == nil {
+		return nil
 	}
+	return p.parser.schemaOf(t)
+}
 
+// requestParamsOf 结构体的所有字段作为请求参数
+//
+// @param newParam 创建请求参数的方法，如：runapi.NewRequestParam
+func requestParamsOf(obj *Object, newParam func(name, tpe, require, value, remark string) runapi.RequestParam) (params []runapi.RequestParam, paramJson string) {
 	requireVal := func(required bool) string {
 		if required {
 			return "1"
@@ -267,7 +500,7 @@ func (p *ApiDoc) parseRequestParam(commentLine string) (params []runapi.RequestP
 		return "0"
 	}
 	for _, field := range obj.AllFields() {
-		param := runapi.NewRequestParam(field.Name, field.Type, requireVal(field.Required), field.Value, field.Comment)
+		param := newParam(field.Name, field.Type, requireVal(field.Required), field.Value, field.Comment)
 		params = append(params, param)
 	}
 	paramJson = jsonFormat(obj.Json())
@@ -285,14 +518,8 @@ func (p *ApiDoc) parseResponseComment(commentLine string) error {
 	if err != nil {
 		return err
 	}
-
-	p.Response.Params = append(p.Response.Params, params...)
-	if p.Response.Example == "" {
-		p.Response.Example = jsonFormat(paramJson)
-	} else if strings.HasPrefix(p.Response.Example, "{") {
-		val, _ := sjson.SetRawBytes([]byte(p.Response.Example), "data", paramJson)
-		p.Response.Example = jsonFormat(val)
-	}
+	p.Response.addParams(params, paramJson)
+	p.Response.addSchema(p.schemaOfComment(commentLine))
 	return nil
 }
 
@@ -302,15 +529,32 @@ func (p *ApiDoc) parseResponseFailComment(commentLine string) error {
 	if err != nil {
 		return err
 	}
+	p.ResponseFail.addParams(params, paramJson)
+	p.ResponseFail.addSchema(p.schemaOfComment(commentLine))
+	return nil
+}
 
-	p.ResponseFail.Params = append(p.ResponseFail.Params, params...)
-	if p.ResponseFail.Example == "" {
-		p.ResponseFail.Example = jsonFormat(paramJson)
-	} else if strings.HasPrefix(p.ResponseFail.Example, "{") {
-		val, _ := sjson.SetRawBytes([]byte(p.ResponseFail.Example), "data", paramJson)
-		p.ResponseFail.Example = jsonFormat(val)
+// addParams 添加返回参数，已经有通用的返回示例时作为其中的 data 字段
+func (p *ApiResponse) addParams(params []runapi.ResponseParam, paramJson []byte) {
+	p.Params = append(p.Params, params...)
+	if p.Example == "" {
+		p.Example = jsonFormat(paramJson)
+	} else if strings.HasPrefix(p.Example, "{") {
+		val, _ := sjson.SetRawBytes([]byte(p.Example), "data", paramJson)
+		p.Example = jsonFormat(val)
+	}
+}
+
+// addSchema 添加结构体返回参数的 Schema，和返回示例一样，已经有通用的返回结构时作为其中的 data 字段
+func (p *ApiResponse) addSchema(schema *Schema) {
+	if schema == nil {
+		return
+	}
+	if p.Schema == nil {
+		p.Schema = schema
+	} else {
+		p.Schema = p.Schema.withField("data", schema)
 	}
-	return nil
 }
 
 func (p *ApiDoc) parseResponseParam(commentLine string) (params []runapi.ResponseParam, paramJson []byte, err error) {
@@ -329,16 +573,16 @@ func (p *ApiDoc) parseResponseParam(commentLine string) (params []runapi.Respons
 		return
 	}
 
-	// 解析对象
-	refType := strings.TrimRight(commentLine, "{}")
-	if refType == "" || p.parser == nil {
-		return
-	}
-	obj, err := p.parser.ParseObject(refType, p.astFile)
+	obj, err := p.parseObjectComment(commentLine, nil)
 	if err != nil || obj == nil {
 		return
 	}
+	params, paramJson = responseParamsOf(obj)
+	return
+}
 
+// responseParamsOf 结构体的所有字段作为返回参数
+func responseParamsOf(obj *Object) (params []runapi.ResponseParam, paramJson []byte) {
 	for _, field := range obj.AllFields() {
 		param := runapi.NewResponseParam(field.Name, field.Type, field.Comment)
 		params = append(params, param)
@@ -347,11 +591,29 @@ func (p *ApiDoc) parseResponseParam(commentLine string) (params []runapi.Respons
 	return
 }
 
+// isAnnotated 是否使用了指定的注释
+func (p *ApiDoc) isAnnotated(attributes ...string) bool {
+	for _, attribute := range attributes {
+		if p.annotated[attribute] {
+			return true
+		}
+	}
+	return false
+}
+
 // Invalid 没有标题或Url，不是有效的API文档
 func (p *ApiDoc) Invalid() bool {
 	return p.Title == "" || p.Request.Url == ""
 }
 
+// DefaultId 默认的文档标识，为完整包名+方法名，如：ginweb/handler/book.Handler.List。没有源码位置时为空
+func (p *ApiDoc) DefaultId() string {
+	if p.Source.Func == "" {
+		return ""
+	}
+	return p.Source.Package + "." + p.Source.Func
+}
+
 // Name 文档分类+标题
 func (p *ApiDoc) Name() string {
 	catalog := p.Catalog
@@ -361,7 +623,7 @@ func (p *ApiDoc) Name() string {
 	return catalog + p.Title
 }
 
-// Json 测试用
+// Json 文档的 JSON 格式，和中间格式中的文档一致
 func (p *ApiDoc) Json() string {
 	data, _ := json.Marshal(p)
 	return string(data)
@@ -50,6 +50,20 @@ func TestApiDoc_ParseUrlComment(t *testing.T) {
 	})
 }
 
+func TestApiDoc_ParseParamModeComment(t *testing.T) {
+	Convey("测试解析 @param_mode 注释", t, func() {
+		doc := &ApiDoc{}
+		So(doc.parseUrlComment("POST /api/v1/book"), ShouldBeNil)
+		So(doc.parseParamModeComment(runapi.ParamModeFormData), ShouldBeNil)
+		So(doc.Request.ParamMode, ShouldEqual, runapi.ParamModeFormData)
+		So(doc.parseParamModeComment("xml"), ShouldNotBeNil)
+
+		So(doc.parseUrlComment("GET /api/v1/book"), ShouldBeNil)
+		So(doc.parseParamModeComment(runapi.ParamModeJson), ShouldNotBeNil)
+		So(doc.Request.ParamMode, ShouldEqual, runapi.ParamModeUrlEncoded)
+	})
+}
+
 func TestApiDoc_ParseReqParamComment(t *testing.T) {
 	Convey("测试解析请求参数", t, func() {
 		paramComment := `page_size	int	true	"30"	"每页显示条数"`
@@ -87,3 +101,85 @@ func TestApiDoc_ParseResponseParamComment(t *testing.T) {
 		So(doc.parseResponseComment(paramComment), ShouldBeNil)
 	})
 }
+
+func TestApiDoc_CheckPathVariables(t *testing.T) {
+	Convey("测试检查路径参数", t, func() {
+		doc := &ApiDoc{}
+		So(doc.parseUrlComment("GET {{BASEURL}}/books/:id/chapters/{chapter}/*filepath?page=1"), ShouldBeNil)
+		So(doc.parsePathVarComment(`chapter int true "" "章节"`), ShouldBeNil)
+		So(doc.parsePathVarComment(`bookId int true "" "书籍 id"`), ShouldBeNil)
+
+		So(doc.checkPathVariables(), ShouldResemble, []string{"bookId"})
+		So(doc.Request.PathVariable, ShouldResemble, []runapi.RequestParam{
+			runapi.NewRequestParam("id", "string", "true", "", ""),
+			runapi.NewRequestParam("chapter", "int", "true", "", "章节"),
+			runapi.NewRequestParam("filepath", "string", "true", "", ""),
+			runapi.NewRequestParam("bookId", "int", "true", "", "书籍 id"),
+		})
+	})
+}
+
+func TestApiDoc_ParseComment_Prefix(t *testing.T) {
+	Convey("测试解析带命名空间前缀的注释", t, func() {
+		comments := []string{
+			"// List 获取书籍列表",
+			"// @showdoc.url GET {{BASEURL}}/api/v1/book/list",
+			"// @ShowDoc.desc 分页获取书籍列表",
+			"// @param page int true \"1\" \"第几页\"",
+		}
+
+		doc := &ApiDoc{prefix: NormalizePrefix("@showdoc.")}
+		for _, comment := range comments {
+			So(doc.ParseComment("List", comment), ShouldBeNil)
+		}
+		So(doc.Title, ShouldEqual, "获取书籍列表")
+		So(doc.Request.Url, ShouldEqual, "{{BASEURL}}/api/v1/book/list")
+		So(doc.Description, ShouldEqual, "分页获取书籍列表")
+		So(len(doc.Request.Query), ShouldEqual, 1)
+
+		// 严格模式忽略没有前缀的注释
+		doc = &ApiDoc{prefix: "sd:", strictPrefix: true}
+		So(doc.ParseComment("List", "// @sd:url GET {{BASEURL}}/api/v1/book/list"), ShouldBeNil)
+		So(doc.ParseComment("List", "// @param page int true \"1\" \"第几页\""), ShouldBeNil)
+		So(doc.ParseComment("List", "// @url POST {{BASEURL}}/other"), ShouldBeNil)
+		So(doc.Request.Url, ShouldEqual, "{{BASEURL}}/api/v1/book/list")
+		So(len(doc.Request.Query), ShouldEqual, 0)
+	})
+}
+
+func TestApiDoc_ParseIdComment(t *testing.T) {
+	Convey("测试解析 @id 注释", t, func() {
+		doc := &ApiDoc{Source: ApiSource{Package: "ginweb/handler/book", Func: "Handler.List"}}
+		So(doc.DefaultId(), ShouldEqual, "ginweb/handler/book.Handler.List")
+
+		So(doc.ParseComment("List", "// @id book.list"), ShouldBeNil)
+		So(doc.Id, ShouldEqual, "book.list")
+
+		So((&ApiDoc{}).DefaultId(), ShouldEqual, "")
+	})
+}
+
+func TestApiDoc_ParseTagsComment(t *testing.T) {
+	Convey("测试解析 @tags 注释", t, func() {
+		general := newApiDoc(nil, nil, nil)
+		So(general.ParseComment("", "// @tags public"), ShouldBeNil)
+
+		doc := newApiDoc(nil, nil, general)
+		So(doc.ParseComment("List", "// @tags admin, , Public,internal"), ShouldBeNil)
+		So(doc.Tags, ShouldResemble, []string{"public", "admin", "internal"})
+		So(general.Tags, ShouldResemble, []string{"public"})
+		So(doc.HasTag("ADMIN"), ShouldBeTrue)
+	})
+
+	Convey("测试按标签过滤文档", t, func() {
+		public := &ApiDoc{Title: "公开", Tags: []string{"public"}}
+		admin := &ApiDoc{Title: "后台", Tags: []string{"public", "admin"}}
+		none := &ApiDoc{Title: "没有标签"}
+		docs := []*ApiDoc{public, admin, none}
+
+		So(FilterTags(docs, nil, nil), ShouldResemble, docs)
+		So(FilterTags(docs, []string{"public"}, nil), ShouldResemble, []*ApiDoc{public, admin})
+		So(FilterTags(docs, nil, []string{"admin"}), ShouldResemble, []*ApiDoc{public, none})
+		So(FilterTags(docs, []string{"public"}, []string{"admin"}), ShouldResemble, []*ApiDoc{public})
+	})
+}
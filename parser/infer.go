@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+
+	"github.com/whaios/goshowdoc/log"
+	"github.com/whaios/goshowdoc/runapi"
+	"golang.org/x/tools/go/packages"
+)
+
+// handlerTypes 从处理请求的方法体中推断的请求和返回类型
+type handlerTypes struct {
+	Param    types.Type // c.ShouldBindJSON(&req)
+	Query    types.Type // c.ShouldBindQuery(&q)
+	Resp     types.Type // c.JSON(http.StatusOK, resp)
+	RespFail types.Type // c.JSON(http.StatusBadRequest, resp)
+}
+
+// 绑定请求 Body 参数的 gin.Context 方法，第一个参数是绑定的变量
+var ginBindMethods = []string{
+	"Bind", "BindJSON", "BindXML", "BindYAML", "BindTOML", "BindWith",
+	"ShouldBind", "ShouldBindJSON", "ShouldBindXML", "ShouldBindYAML", "ShouldBindTOML", "ShouldBindWith", "ShouldBindBodyWith",
+}
+
+// 绑定请求 Query 参数的 gin.Context 方法
+var ginBindQueryMethods = []string{"BindQuery", "ShouldBindQuery"}
+
+// 返回 JSON 的 gin.Context 方法，第一个参数是状态码，最后一个参数是返回的对象
+var ginRenderMethods = []string{"JSON", "IndentedJSON", "SecureJSON", "AsciiJSON", "PureJSON", "AbortWithStatusJSON"}
+
+// inferHandlerTypes 分析方法体中 gin.Context 的参数绑定和返回调用，推断请求和返回类型。
+// 每种类型使用第一个调用中的类型，只推断结构体类型。
+func (p *Parser) inferHandlerTypes(astFile *ast.File, funcDecl *ast.FuncDecl) *handlerTypes {
+	info, ok := p.packages.files[astFile]
+	if !ok || info.Pkg.TypesInfo == nil || funcDecl.Body == nil {
+		return nil
+	}
+	pkg := info.Pkg
+
+	result := &handlerTypes{}
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || !isNamedType(pkg.TypesInfo.TypeOf(sel.X), ginPkgPaths, "Context") {
+			return true
+		}
+		switch name := sel.Sel.Name; {
+		case inStrings(name, ginBindMethods):
+			setInferredType(&result.Param, pkg, call.Args[0])
+		case inStrings(name, ginBindQueryMethods):
+			setInferredType(&result.Query, pkg, call.Args[0])
+		case inStrings(name, ginRenderMethods) && len(call.Args) >= 2:
+			obj := call.Args[len(call.Args)-1]
+			if isFailStatus(pkg, call.Args[0]) {
+				setInferredType(&result.RespFail, pkg, obj)
+			} else {
+				setInferredType(&result.Resp, pkg, obj)
+			}
+		}
+		return true
+	})
+	return result
+}
+
+func setInferredType(dst *types.Type, pkg *packages.Package, expr ast.Expr) {
+	if *dst != nil {
+		return
+	}
+	t := pkg.TypesInfo.TypeOf(expr)
+	if t == nil || !isStructType(derefType(t)) {
+		log.Debug("忽略推断的类型（不是结构体）: %s", types.ExprString(expr))
+		return
+	}
+	*dst = derefType(t)
+}
+
+// isFailStatus 状态码是否常量并且不是 2xx
+func isFailStatus(pkg *packages.Package, expr ast.Expr) bool {
+	tv, ok := pkg.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.Int {
+		return false
+	}
+	code, ok := constant.Int64Val(tv.Value)
+	return ok && (code < 200 || code >= 300)
+}
+
+// inferTypes 没有使用 @param、@query、@resp、@resp_fail 注释时，使用从方法体中推断的类型
+func (p *Parser) inferTypes(doc *ApiDoc, astFile *ast.File, funcDecl *ast.FuncDecl) error {
+	inferred := p.inferHandlerTypes(astFile, funcDecl)
+	if inferred == nil {
+		return nil
+	}
+	if inferred.Param != nil && !doc.isAnnotated("@param") {
+		obj, err := p.parseObject(inferred.Param, nil, nil)
+		if err != nil {
+			return err
+		}
+		doc.addParams(requestParamsOf(obj, runapi.NewRequestParam))
+		doc.addParamSchema(p.schemaOf(inferred.Param))
+	}
+	if inferred.Query != nil && !doc.isAnnotated("@query") {
+		obj, err := p.parseObject(inferred.Query, nil, QueryTagKeys)
+		if err != nil {
+			return err
+		}
+		params, _ := requestParamsOf(obj, runapi.NewRequestParam)
+		doc.Request.Query = append(doc.Request.Query, params...)
+	}
+	if inferred.Resp != nil && !doc.isAnnotated("@resp", "@response") {
+		obj, err := p.parseObject(inferred.Resp, nil, nil)
+		if err != nil {
+			return err
+		}
+		doc.Response.addParams(responseParamsOf(obj))
+		doc.Response.addSchema(p.schemaOf(inferred.Resp))
+	}
+	if inferred.RespFail != nil && !doc.isAnnotated("@resp_fail", "@response_fail") {
+		obj, err := p.parseObject(inferred.RespFail, nil, nil)
+		if err != nil {
+			return err
+		}
+		doc.ResponseFail.addParams(responseParamsOf(obj))
+		doc.ResponseFail.addSchema(p.schemaOf(inferred.RespFail))
+	}
+	return nil
+}
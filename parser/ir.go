@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+// IRVersion 中间格式的版本，字段有不兼容的修改时加 1
+const IRVersion = 1
+
+// IR 文档的中间格式（Intermediate Representation），由 dump 命令输出，load 命令读取后更新到 ShowDoc。
+// 其他工具可以读取或修改中间格式中的文档，不需要再解析 Go 源码。
+//
+//	{
+//	  "version": 1,
+//	  "docs": [{"title": "...", "catalog": "...", "source": {...}, "request": {...}, "response": {...}, ...}],
+//	  "schemas": {"book.Book": {...}}
+//	}
+type IR struct {
+	Version int                `json:"version"` // 中间格式的版本，即 IRVersion
+	Docs    []*ApiDoc          `json:"docs"`    // 解析注释生成的文档，按生成顺序排列
+	Schemas map[string]*Schema `json:"schemas"` // 文档中引用的结构体类型的 Schema 定义，引用格式为 #/components/schemas/<名称>
+}
+
+// IR 解析结果的中间格式
+func (p *Parser) IR() *IR {
+	return &IR{
+		Version: IRVersion,
+		Docs:    p.Docs,
+		Schemas: p.Schemas,
+	}
+}
+
+// Json 格式化的 JSON 文档
+func (ir *IR) Json() []byte {
+	data, _ := json.MarshalIndent(ir, "", "  ")
+	return data
+}
+
+// LoadIR 读取中间格式的文件
+func LoadIR(fileName string) (*IR, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return ParseIR(data)
+}
+
+// ParseIR 解析中间格式，检查版本并补全缺少的字段，其他工具生成的文档可以省略空的参数列表
+func ParseIR(data []byte) (*IR, error) {
+	ir := &IR{}
+	if err := json.Unmarshal(data, ir); err != nil {
+		return nil, fmt.Errorf("解析中间格式出错: %v", err)
+	}
+	if ir.Version <= 0 {
+		return nil, fmt.Errorf("不是有效的中间格式，缺少 version")
+	}
+	if ir.Version > IRVersion {
+		return nil, fmt.Errorf("不支持的中间格式版本 %d，当前支持的最高版本为 %d，请升级 goshowdoc", ir.Version, IRVersion)
+	}
+	if ir.Schemas == nil {
+		ir.Schemas = make(map[string]*Schema)
+	}
+	for i, doc := range ir.Docs {
+		if doc == nil || doc.Title == "" || doc.Request.Url == "" {
+			return nil, fmt.Errorf("第 %d 个文档没有 title 或 url", i+1)
+		}
+		doc.normalize()
+	}
+	return ir, nil
+}
+
+// normalize 补全读取的文档中缺少的字段，和解析注释生成的文档保持一致
+func (p *ApiDoc) normalize() {
+	if p.Id == "" {
+		p.Id = p.DefaultId()
+	}
+	if p.Order == "" {
+		p.Order = "99"
+	}
+	if p.Request.Method == "" {
+		p.Request.Method = runapi.MethodGet
+	}
+	if p.Request.ParamMode == "" {
+		p.Request.ParamMode = runapi.ParamModeUrlEncoded
+	}
+	for _, params := range []*[]runapi.RequestParam{&p.Request.Headers, &p.Request.PathVariable, &p.Request.Query, &p.Request.Params} {
+		if *params == nil {
+			*params = make([]runapi.RequestParam, 0)
+		}
+	}
+	for _, params := range []*[]runapi.ResponseParam{&p.Response.Params, &p.ResponseFail.Params} {
+		if *params == nil {
+			*params = make([]runapi.ResponseParam, 0)
+		}
+	}
+}
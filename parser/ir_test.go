@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+func TestIR(t *testing.T) {
+	Convey("测试中间格式", t, func() {
+		p := NewParser()
+		p.RouteDirs = []string{"../example/ginweb/router"}
+		So(p.ParseApiDoc("../example/ginweb/handler"), ShouldBeNil)
+
+		ir, err := ParseIR(p.IR().Json())
+		So(err, ShouldBeNil)
+		So(ir.Version, ShouldEqual, IRVersion)
+		So(len(ir.Docs), ShouldEqual, len(p.Docs))
+		for i, doc := range ir.Docs {
+			So(doc.Json(), ShouldEqual, p.Docs[i].Json())
+		}
+		So(ir.Docs[0].Source, ShouldResemble, ApiSource{
+			File:    "../example/ginweb/handler/book/handler.go",
+			Line:    28,
+			Package: "ginweb/handler/book",
+			Func:    "Handler.List",
+		})
+		So(ir.Schemas["book.Book"], ShouldResemble, p.Schemas["book.Book"])
+	})
+
+	Convey("测试读取其他工具生成的中间格式", t, func() {
+		ir, err := ParseIR([]byte(`{"version":1,"docs":[{"title":"健康检查","request":{"url":"{{BASEURL}}/ping"}}]}`))
+		So(err, ShouldBeNil)
+		doc := ir.Docs[0]
+		So(doc.Order, ShouldEqual, "99")
+		So(doc.Request.Method, ShouldEqual, runapi.MethodGet)
+		So(doc.Request.ParamMode, ShouldEqual, runapi.ParamModeUrlEncoded)
+		So(doc.Request.Headers, ShouldNotBeNil)
+		So(doc.Response.Params, ShouldNotBeNil)
+		So(ir.Schemas, ShouldNotBeNil)
+
+		_, err = ParseIR([]byte(`{"docs":[]}`))
+		So(err, ShouldNotBeNil)
+		_, err = ParseIR([]byte(`{"version":2,"docs":[]}`))
+		So(err, ShouldNotBeNil)
+		_, err = ParseIR([]byte(`{"version":1,"docs":[{"title":"没有 url"}]}`))
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	gen "github.com/darjun/json-gen"
@@ -20,6 +21,7 @@ func NewField(name, typeName string, required bool, comment string) *Field {
 		Type:     typeName,
 		Required: required,
 		Comment:  comment,
+		desc:     comment,
 	}
 }
 
@@ -38,7 +40,34 @@ type Field struct {
 	Value    string // 字段的模拟值
 	Comment  string // 字段同行注释
 
-	fields []*Field
+	desc    string // 字段注释，不包含补充的说明，作为字符串字段的示例值
+	example string // 字段的示例值，为空时根据类型生成
+	fields  []*Field
+}
+
+// AddRemark 在字段注释后面添加补充说明，如校验规则和枚举值
+func (f *Field) AddRemark(remark string) {
+	if remark == "" {
+		return
+	}
+	if f.Comment != "" && !strings.HasPrefix(remark, "（") {
+		f.Comment += " "
+	}
+	f.Comment += remark
+}
+
+// SetEnums 设置字段的枚举值，在注释后面添加枚举值说明，并使用第一个枚举值作为示例值。
+// 如：订单状态 1=新建, 2=已支付
+func (f *Field) SetEnums(enums []*EnumValue) {
+	if len(enums) == 0 {
+		return
+	}
+	items := make([]string, 0, len(enums))
+	for _, enum := range enums {
+		items = append(items, enum.String())
+	}
+	f.AddRemark(strings.Join(items, ", "))
+	f.example = enums[0].ValueString()
 }
 
 // AllFields 所有字段数组，包含子对象字段
@@ -80,17 +109,31 @@ func (obj *Object) PutField(field *Field) {
 		"int32",
 		"int64":
 		fv = "0"
+		if v, err := strconv.ParseInt(field.example, 10, 64); err == nil {
+			fv = field.example
+			obj.json.PutInt(field.Name, v)
+			break
+		}
 		obj.json.PutInt(field.Name, 0)
 	case "float32",
 		"float64":
 		fv = "0.00"
+		if v, err := strconv.ParseFloat(field.example, 64); err == nil {
+			fv = field.example
+			obj.json.PutFloat(field.Name, v)
+			break
+		}
 		obj.json.PutFloat(field.Name, 0.00)
 	case "bool":
 		fv = "false"
 		obj.json.PutBool(field.Name, false)
 	default:
-		fv = ""
-		obj.json.PutString(field.Name, field.Comment)
+		fv = field.example
+		if field.example != "" {
+			obj.json.PutString(field.Name, field.example)
+			break
+		}
+		obj.json.PutString(field.Name, field.desc)
 	}
 
 	field.Value = fv
@@ -120,12 +163,14 @@ func (obj *Object) PutArray(field *Field) {
 		"int16",
 		"int32",
 		"int64":
-		arr.AppendInt(0)
+		v, _ := strconv.ParseInt(field.example, 10, 64)
+		arr.AppendInt(v)
 	case "float32",
 		"float64":
-		arr.AppendFloat(0.00)
+		v, _ := strconv.ParseFloat(field.example, 64)
+		arr.AppendFloat(v)
 	case "string":
-		arr.AppendString("")
+		arr.AppendString(field.example)
 	}
 
 	obj.json.PutArray(field.Name, arr)
@@ -1,216 +1,401 @@
 package parser
 
 import (
+	"fmt"
 	"go/ast"
+	"go/constant"
+	"go/parser"
 	"go/token"
+	"go/types"
+	"path/filepath"
 	"strings"
 
 	"github.com/whaios/goshowdoc/log"
 	"golang.org/x/tools/go/packages"
 )
 
+// loadMode 加载包时需要的信息。
+// 依赖的包也从源码加载，因为需要语法树获取其他包中结构体字段的注释，同时避免依赖编译器导出数据的版本。
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports |
+	packages.NeedDeps | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax | packages.NeedModule
+
 func NewPackages() *Packages {
 	return &Packages{
-		files:             make(map[*ast.File]*AstFileInfo),
-		packages:          make(map[string]*Package),
-		uniqueDefinitions: make(map[string]*TypeSpecDef),
+		fset:     token.NewFileSet(),
+		files:    make(map[*ast.File]*AstFileInfo),
+		packages: make(map[string]*packages.Package),
+		indexed:  make(map[string]bool),
+		fields:   make(map[token.Pos]*ast.Field),
+		enums:    make(map[*types.TypeName][]*EnumValue),
 	}
 }
 
-// Packages 存储扫描到的 Go 文件、包路径和他们之间的关系。
-// 主要用于解析注释中可能会引用到的类型。
+// Packages 存储加载的 Go 包、文件和他们之间的关系。
+// 类型由 go/types 类型检查器解析，语法树主要用于获取注释。
 type Packages struct {
-	projectDir string // 目标Go项目所在目录（支持绝对路径和相对路径），用于加载外部包时获取go包名，为空时默认为当前运行目录。
+	fset *token.FileSet
+
+	files    map[*ast.File]*AstFileInfo       // 目标目录下的所有go文件
+	packages map[string]*packages.Package     // key=完整包名，包含依赖的包. 如：ginweb/model/book
+	indexed  map[string]bool                  // 已经收集过字段的包
+	fields   map[token.Pos]*ast.Field         // 结构体字段定义，key=字段名位置
+	enums    map[*types.TypeName][]*EnumValue // 自定义类型的常量，key=常量的类型
+}
+
+// Load 加载指定目录下的所有包及其依赖的包，返回目录下的包。
+// 所有目录在同一次加载中解析，这样不同目录下的包引用的类型是一致的。
+//
+// @param dirs 如："../example/ginweb/handler"，第一个目录作为执行 go 命令的目录
+func (p *Packages) Load(dirs ...string) ([]*packages.Package, error) {
+	log.Debug("加载目录下的包: %s", strings.Join(dirs, ", "))
+	var absDirs, patterns []string
+	for _, dir := range dirs {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		absDirs = append(absDirs, absDir)
+		patterns = append(patterns, absDir+"/...")
+	}
+	cfg := &packages.Config{
+		Dir:  dirs[0],
+		Mode: loadMode,
+		Fset: p.fset,
+		ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+			astFile, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+			if astFile != nil && !inDirs(filename, absDirs) {
+				// 目录以外的包只需要类型和注释，忽略方法体可以加快类型检查
+				for _, decl := range astFile.Decls {
+					if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+						funcDecl.Body = nil
+					}
+				}
+			}
+			return astFile, err
+		},
+	}
+	roots, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("加载包失败, dir: %s, error: %s", dirs[0], err.Error())
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("目录下没有 Go 源码文件: %s", dirs[0])
+	}
+
+	packages.Visit(roots, nil, func(pkg *packages.Package) {
+		p.packages[pkg.PkgPath] = pkg
+	})
+	for _, pkg := range roots {
+		// 类型检查出错时仍然可以得到部分类型信息，不中断解析
+		for _, pkgErr := range pkg.Errors {
+			log.Warn("包 %s 存在错误: %s", pkg.PkgPath, pkgErr.Error())
+		}
+		for i, astFile := range pkg.Syntax {
+			p.AddFile(pkg, pkg.CompiledGoFiles[i], astFile)
+		}
+	}
+	return roots, nil
+}
+
+// PackagesInDir 过滤出指定目录下的包
+func PackagesInDir(pkgs []*packages.Package, dir string) []*packages.Package {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	var result []*packages.Package
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) > 0 && inDirs(pkg.GoFiles[0], []string{absDir}) {
+			result = append(result, pkg)
+		}
+	}
+	return result
+}
 
-	files    map[*ast.File]*AstFileInfo // 使用到的所有go文件
-	packages map[string]*Package        // key=完整包名. 如：ginweb/book
-	// 在目录下收集到的具有唯一名称（完整包名+类型名）的类型。如果存在同名的不会出现到该字典中。
-	uniqueDefinitions map[string]*TypeSpecDef // key=类型全名. 如：ginweb.handler.book.Book
+func inDirs(fileName string, absDirs []string) bool {
+	for _, absDir := range absDirs {
+		if strings.HasPrefix(fileName, absDir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
 }
 
-// AddFile 添加 Go 源码文件，并解析代码中的类型
-func (p *Packages) AddFile(pkgPath, fileName string, astFile *ast.File) *AstFileInfo {
+// AddFile 添加 Go 源码文件
+func (p *Packages) AddFile(pkg *packages.Package, fileName string, astFile *ast.File) *AstFileInfo {
 	info := &AstFileInfo{
 		File:     astFile,
 		FileName: fileName,
-		PkgPath:  pkgPath,
+		PkgPath:  pkg.PkgPath,
+		Pkg:      pkg,
 	}
 	p.files[astFile] = info
 	log.Debug("收集Go文件: %s", info.FileName)
-
-	// 解析go文件中的结构体
-	p.parseTypesFromFile(info.File, info.PkgPath)
 	return info
 }
 
-// 解析go文件中的结构体
-func (p *Packages) parseTypesFromFile(astFile *ast.File, pkgPath string) {
-	for _, astDeclaration := range astFile.Decls {
-		if generalDeclaration, ok := astDeclaration.(*ast.GenDecl); ok && generalDeclaration.Tok == token.TYPE {
-			for _, astSpec := range generalDeclaration.Specs {
-				if typeSpec, ok := astSpec.(*ast.TypeSpec); ok {
-					typeSpecDef := &TypeSpecDef{
-						PkgPath:  pkgPath,
-						File:     astFile,
-						TypeSpec: typeSpec,
-					}
+// indexPackage 收集包中所有的结构体字段定义和常量定义
+func (p *Packages) indexPackage(pkgPath string) {
+	if p.indexed[pkgPath] {
+		return
+	}
+	p.indexed[pkgPath] = true
 
-					fullName := typeSpecDef.FullName()
-					log.Debug("收集类型: %s", fullName)
-					anotherTypeDef, ok := p.uniqueDefinitions[fullName]
-					if ok {
-						if typeSpecDef.PkgPath == anotherTypeDef.PkgPath {
-							continue
-						} else {
-							delete(p.uniqueDefinitions, fullName)
-						}
-					} else {
-						p.uniqueDefinitions[fullName] = typeSpecDef
-					}
+	pkg, ok := p.packages[pkgPath]
+	if !ok {
+		return
+	}
+	log.Debug("收集包中的类型: %s", pkgPath)
+	for _, astFile := range pkg.Syntax {
+		p.parseTypesFromFile(pkg, astFile)
+	}
+}
 
-					if p.packages[typeSpecDef.PkgPath] == nil {
-						p.packages[typeSpecDef.PkgPath] = &Package{
-							Name:            astFile.Name.Name,
-							TypeDefinitions: map[string]*TypeSpecDef{typeSpecDef.Name(): typeSpecDef},
-						}
-					} else if _, ok = p.packages[typeSpecDef.PkgPath].TypeDefinitions[typeSpecDef.Name()]; !ok {
-						p.packages[typeSpecDef.PkgPath].TypeDefinitions[typeSpecDef.Name()] = typeSpecDef
-					}
+// 解析go文件中的结构体字段，以及自定义类型的常量
+func (p *Packages) parseTypesFromFile(pkg *packages.Package, astFile *ast.File) {
+	ast.Inspect(astFile, func(node ast.Node) bool {
+		if st, ok := node.(*ast.StructType); ok {
+			for _, field := range st.Fields.List {
+				for _, name := range field.Names {
+					p.fields[name.Pos()] = field
 				}
 			}
 		}
+		return true
+	})
+
+	// 标准库中的常量不作为枚举值，如：time.Nanosecond
+	if pkg.Module == nil || pkg.Types == nil {
+		return
+	}
+	for _, decl := range astFile.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			valueSpec := spec.(*ast.ValueSpec)
+			for _, name := range valueSpec.Names {
+				if name.Name == "_" {
+					continue
+				}
+				obj, ok := pkg.Types.Scope().Lookup(name.Name).(*types.Const)
+				if !ok {
+					continue
+				}
+				named, ok := obj.Type().(*types.Named)
+				if !ok || named.Obj().Pkg() == nil {
+					continue
+				}
+				p.enums[named.Obj()] = append(p.enums[named.Obj()], &EnumValue{
+					Name:    name.Name,
+					Value:   obj.Val(),
+					Comment: declComment(valueSpec.Comment, valueSpec.Doc, name.Name),
+				})
+			}
+		}
 	}
 }
 
-// FindTypeSpec 查找类型
+// FindType 查找类型
 //
-// @param shortName 包名.类型名，如：ListRsp 或 book.Book
-func (p *Packages) FindTypeSpec(shortName string, file *ast.File) *TypeSpecDef {
+// @param typeName 类型名，如：ListRsp、book.Book 或 Result[[]ListItem]。
+// 在 file 的文件作用域中解析；file 为空时 typeName 需要使用完整包名，如：ginweb/model/book.Book
+func (p *Packages) FindType(typeName string, file *ast.File) types.Type {
+	var pkg *packages.Package
+	exprStr := typeName
 	if file == nil { // for test
-		return p.uniqueDefinitions[shortName]
-	}
-
-	var pkgName, typeName string
-	{
-		typeName = shortName
-		if parts := strings.SplitN(shortName, ".", 2); len(parts) == 2 {
-			pkgName = parts[0]
-			typeName = parts[1]
+		baseName := typeName
+		if i := strings.Index(typeName, "["); i > 0 {
+			baseName = typeName[:i] // 只在类型名称中查找包名，忽略类型实参
 		}
-	}
-
-	// 有包名，查找外部包
-	if pkgName != "" {
-		// 从文件中导入的包中查找指定包路径
-		imptPkgPath, _ := p.findPackagePathFromImports(pkgName, file)
-		// 没有找到对应的包名
-		if imptPkgPath == "" {
+		i := strings.LastIndex(baseName, ".")
+		if i < 0 {
 			return nil
 		}
-
-		// 收集外部包
-		p.loadExternalPackage(imptPkgPath)
-		return p.findTypeSpec(imptPkgPath, typeName)
+		pkg = p.packages[baseName[:i]]
+		exprStr = typeName[i+1:]
+	} else if info, ok := p.files[file]; ok {
+		pkg = info.Pkg
 	}
-
-	var pkgPath, fullName string
-	if fileInfo, ok := p.files[file]; ok {
-		pkgPath = fileInfo.PkgPath
-		fullName = pkgPath + "." + typeName
+	if pkg == nil || pkg.Types == nil {
+		return nil
 	}
 
-	// 从目录包中查找
-	typeDef, ok := p.uniqueDefinitions[fullName]
-	if ok {
-		return typeDef
+	expr, err := parser.ParseExpr(exprStr)
+	if err != nil {
+		log.Debug("解析类型 %s 失败: %s", typeName, err.Error())
+		return nil
 	}
-
-	typeDef = p.findTypeSpec(pkgPath, typeName)
-	if typeDef != nil {
-		return typeDef
+	t, err := p.evalType(expr, pkg, file)
+	if err != nil {
+		log.Debug("解析类型 %s 失败: %s", typeName, err.Error())
+		return nil
 	}
+	return t
+}
 
-	// 载入 . 包
-	for _, imp := range file.Imports {
-		if imp.Name != nil && imp.Name.Name == "." {
-			imptPkgPath := strings.Trim(imp.Path.Value, `"`)
-			// 收集外部包
-			p.loadExternalPackage(imptPkgPath)
-
-			if typeDef = p.findTypeSpec(imptPkgPath, typeName); typeDef != nil {
-				return typeDef
-			}
+// evalType 在文件作用域中解析类型表达式。
+// 和 types.Eval 不同，包名会按导入包的真实包名查找，所以 _ 导入的包也可以在注释中引用。
+func (p *Packages) evalType(expr ast.Expr, pkg *packages.Package, file *ast.File) (types.Type, error) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		scope := pkg.Types.Scope()
+		if fileScope, ok := pkg.TypesInfo.Scopes[file]; ok {
+			scope = fileScope
 		}
+		_, obj := scope.LookupParent(e.Name, token.NoPos)
+		return typeNameType(obj, e.Name)
+	case *ast.SelectorExpr: // 包名.类型
+		x, ok := e.X.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("不支持的类型表达式")
+		}
+		imported := p.findImportedPackage(x.Name, pkg, file)
+		if imported == nil {
+			return nil, fmt.Errorf("没有找到包: %s", x.Name)
+		}
+		return typeNameType(imported.Scope().Lookup(e.Sel.Name), x.Name+"."+e.Sel.Name)
+	case *ast.StarExpr: // 指针
+		elem, err := p.evalType(e.X, pkg, file)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewPointer(elem), nil
+	case *ast.ArrayType:
+		elem, err := p.evalType(e.Elt, pkg, file)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewSlice(elem), nil
+	case *ast.MapType:
+		key, err := p.evalType(e.Key, pkg, file)
+		if err != nil {
+			return nil, err
+		}
+		elem, err := p.evalType(e.Value, pkg, file)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewMap(key, elem), nil
+	case *ast.InterfaceType:
+		return types.NewInterfaceType(nil, nil), nil
+	case *ast.ParenExpr:
+		return p.evalType(e.X, pkg, file)
+	case *ast.IndexExpr: // 泛型类型，一个类型实参
+		return p.instantiate(e.X, []ast.Expr{e.Index}, pkg, file)
+	case *ast.IndexListExpr: // 泛型类型，多个类型实参
+		return p.instantiate(e.X, e.Indices, pkg, file)
 	}
-	return nil
+	return nil, fmt.Errorf("不支持的类型表达式")
 }
 
-// findTypeSpec 从收集的指定包中查找类型
-//
-// @param pkgPath 如：refstruct/employee
-// @param typeName 如：Employee
-func (p *Packages) findTypeSpec(pkgPath string, typeName string) *TypeSpecDef {
-	if p.packages == nil {
-		return nil
+// instantiate 使用类型实参实例化泛型类型
+func (p *Packages) instantiate(genericExpr ast.Expr, argExprs []ast.Expr, pkg *packages.Package, file *ast.File) (types.Type, error) {
+	generic, err := p.evalType(genericExpr, pkg, file)
+	if err != nil {
+		return nil, err
 	}
-	pd, found := p.packages[pkgPath]
-	if found {
-		typeSpec, ok := pd.TypeDefinitions[typeName]
-		if ok {
-			return typeSpec
+	args := make([]types.Type, 0, len(argExprs))
+	for _, argExpr := range argExprs {
+		arg, err := p.evalType(argExpr, pkg, file)
+		if err != nil {
+			return nil, err
 		}
+		args = append(args, arg)
 	}
-	return nil
+	return types.Instantiate(nil, generic, args, true)
 }
 
-// imptPkgPath 加载指定包
-func (p *Packages) loadExternalPackage(imptPkgPath string) {
-	if p.packages != nil {
-		if _, ok := p.packages[imptPkgPath]; ok {
-			// 已经收集过该包
-			return
+// findImportedPackage 按包名查找文件导入的包，包括 _ 导入的包。
+// file 为空时从 pkg 导入的所有包中查找。
+func (p *Packages) findImportedPackage(pkgName string, pkg *packages.Package, file *ast.File) *types.Package {
+	if fileScope, ok := pkg.TypesInfo.Scopes[file]; ok {
+		if pkgNameObj, ok := fileScope.Lookup(pkgName).(*types.PkgName); ok {
+			return pkgNameObj.Imported()
 		}
 	}
-	log.Debug("加载外部包: %s", imptPkgPath)
 
-	cfg := &packages.Config{
-		Dir:  p.projectDir,
-		Mode: packages.NeedImports | packages.NeedTypes | packages.NeedSyntax | packages.NeedCompiledGoFiles,
+	var imptPkgPaths []string
+	if file != nil {
+		for _, imp := range file.Imports {
+			imptPkgPaths = append(imptPkgPaths, strings.Trim(imp.Path.Value, `"`))
+		}
+	} else {
+		for imptPkgPath := range pkg.Imports {
+			imptPkgPaths = append(imptPkgPaths, imptPkgPath)
+		}
 	}
-	pkgs, _ := packages.Load(cfg, imptPkgPath)
-	for _, pkg := range pkgs {
-		for i, astFile := range pkg.Syntax {
-			p.AddFile(pkg.ID, pkg.CompiledGoFiles[i], astFile)
+	for _, imptPkgPath := range imptPkgPaths {
+		if impt, ok := pkg.Imports[imptPkgPath]; ok && impt.Types != nil && impt.Types.Name() == pkgName {
+			return impt.Types
 		}
 	}
+	return nil
 }
 
-// 从文件中导入的包中查找指定包路径。
-// @pkgName 包名
-func (p *Packages) findPackagePathFromImports(pkgName string, file *ast.File) (pkgPath string, isAliasPkgName bool) {
-	for _, imp := range file.Imports {
-		// 有别名的包，别名相同，直接取得该包路径
-		if imp.Name != nil && imp.Name.Name == pkgName {
-			pkgPath = strings.Trim(imp.Path.Value, `"`)
-			isAliasPkgName = true
-			break
-		}
+func typeNameType(obj types.Object, name string) (types.Type, error) {
+	typeName, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("%s 不是类型", name)
+	}
+	return typeName.Type(), nil
+}
 
-		// 普通导入，包没有别名
-		path := strings.Trim(imp.Path.Value, `"`)
-		paths := strings.Split(path, "/")
-		if paths[len(paths)-1] == pkgName {
-			// 找到包路径
-			pkgPath = path
-			break
-		}
+// FindField 查找结构体字段的定义
+func (p *Packages) FindField(field *types.Var) *ast.Field {
+	if field.Pkg() == nil {
+		return nil
+	}
+	p.indexPackage(field.Pkg().Path())
+	return p.fields[field.Pos()]
+}
+
+// FindEnums 查找自定义类型的常量，如：
+//
+//	type OrderStatus int
+//	const (
+//		StatusNew  OrderStatus = iota + 1 // 新建
+//		StatusPaid                        // 已支付
+//	)
+func (p *Packages) FindEnums(t types.Type) []*EnumValue {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return nil
+	}
+	obj := named.Origin().Obj()
+	p.indexPackage(obj.Pkg().Path())
+	return p.enums[obj]
+}
+
+// EnumValue 自定义类型的常量值
+type EnumValue struct {
+	Name    string         // 常量名
+	Value   constant.Value // 常量值
+	Comment string         // 常量注释
+}
+
+// String 枚举值的说明，如：1=新建
+func (e *EnumValue) String() string {
+	comment := e.Comment
+	if comment == "" {
+		comment = e.Name
+	}
+	return e.ValueString() + "=" + comment
+}
+
+// ValueString 常量值，字符串常量不带引号
+func (e *EnumValue) ValueString() string {
+	if e.Value.Kind() == constant.String {
+		return constant.StringVal(e.Value)
 	}
-	return
+	return e.Value.ExactString()
 }
 
 // AstFileInfo ast.File 文件信息.
 type AstFileInfo struct {
 	File     *ast.File
-	FileName string // Go 源码文件全名称
-	PkgPath  string // Go 源码文件完整包名
+	FileName string            // Go 源码文件全名称
+	PkgPath  string            // Go 源码文件完整包名
+	Pkg      *packages.Package // Go 源码文件所在的包，包含类型信息
 }
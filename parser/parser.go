@@ -3,17 +3,17 @@ package parser
 import (
 	"fmt"
 	"go/ast"
-	"go/build"
-	"go/parser"
-	"go/token"
+	"go/types"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/whaios/goshowdoc/log"
+	"golang.org/x/tools/go/types/typeutil"
 )
 
 func NewParser() *Parser {
@@ -21,20 +21,44 @@ func NewParser() *Parser {
 		files:    make([]*AstFileInfo, 0),
 		packages: NewPackages(),
 		Docs:     make([]*ApiDoc, 0),
+		Schemas:  make(map[string]*Schema),
+
+		RouteExtractors: DefaultRouteExtractors,
+		Dialect:         DialectShowDoc,
 	}
 }
 
 type Parser struct {
 	packages *Packages      // 解析中引用到的所有文件和包
 	files    []*AstFileInfo // 解析注释的go文件
-	Docs     []*ApiDoc      // 解析注释生成的文档
+	routes   map[*types.Func]*Route
+	Docs     []*ApiDoc // 解析注释生成的文档
+
+	Schemas     map[string]*Schema // 文档中引用的结构体类型的 Schema 定义，key=定义名称，如：book.Book
+	schemaNames typeutil.Map       // 已经定义的结构体类型对应的定义名称
+
+	RouteDirs       []string         // 路由注册代码所在的目录，用于推断没有 @url 注释的接口地址
+	RouteExtractors []RouteExtractor // 支持的路由框架，默认支持 gin、net/http、chi 和 echo
+	InferTypes      bool             // 没有 @param、@query 和 @resp 注释时，从方法体中推断请求和返回类型
+	StrictPathVars  bool             // @path_var 注释的参数不在 url 中时返回错误，否则只输出警告
+	Dialect         Dialect          // 注释的语法，默认为 DialectShowDoc
+
+	AnnotationPrefix string // 注释标签的命名空间前缀，避免和其他工具的注释冲突。如：showdoc. 对应 @showdoc.url，sd: 对应 @sd:url
+	StrictPrefix     bool   // 严格模式，忽略没有前缀的注释标签
+
+	// Exclude 不解析注释的文件，glob 格式。没有斜杆的格式匹配文件名或目录名，如：*_mock.go、testdata；
+	// 有斜杆的格式匹配当前目录的相对路径或其中的目录，如：internal/admin、handler/*/legacy.go、./main.go
+	Exclude []string
 }
 
 // ParseApiDoc 解析指定目录下的 Go 代码文件注释，并生成文档。
-// @param searchDir 目录下必须有 Go 代码文件
-func (p *Parser) ParseApiDoc(searchDir string) error {
+// @param searchDirs 目录下必须有 Go 代码文件，可以指定多个
+func (p *Parser) ParseApiDoc(searchDirs ...string) error {
+	if len(searchDirs) == 0 {
+		return fmt.Errorf("没有指定搜索 Go 源码文件的目录")
+	}
 	// 收集指定目录下的 Go 代码文件，并解析类型
-	if err := p.collectGoFile(searchDir); err != nil {
+	if err := p.collectGoFile(searchDirs...); err != nil {
 		return err
 	}
 
@@ -50,50 +74,82 @@ func (p *Parser) ParseApiDoc(searchDir string) error {
 	return nil
 }
 
-// GetAllGoFileInfo 获取指定目录下的所有Go代码文件.
-// @param searchDir 如："../example/ginweb/handler"
-func (p *Parser) collectGoFile(searchDir string) error {
-	p.packages.projectDir = searchDir
+// FilterTags 按标签过滤生成的文档，并去掉过滤后的文档没有引用的 Schema 定义，避免导出的文档中包含其他接口的类型
+//
+// @param include 为空时不限制，否则只保留有任意一个标签的文档
+// @param exclude 去掉有任意一个标签的文档
+func (p *Parser) FilterTags(include, exclude []string) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return
+	}
+	p.Docs = FilterTags(p.Docs, include, exclude)
+	var roots []*Schema
+	for _, doc := range p.Docs {
+		roots = append(roots, doc.Request.ParamSchema, doc.Response.Schema, doc.ResponseFail.Schema)
+	}
+	p.Schemas = UsedSchemas(roots, p.Schemas)
+}
 
-	// packageDir = "ginweb/handler"
-	packageDir, err := dirToGoPkg(searchDir)
+// collectGoFile 加载指定目录下的所有包，并收集其中的Go代码文件，忽略 Exclude 匹配的文件.
+// @param searchDirs 如："../example/ginweb/handler"
+func (p *Parser) collectGoFile(searchDirs ...string) error {
+	roots, err := p.packages.Load(append(append([]string{}, searchDirs...), p.RouteDirs...)...)
 	if err != nil {
-		return fmt.Errorf("获取包名失败, dir: %s, error: %s", searchDir, err.Error())
+		return err
 	}
-
-	return filepath.Walk(searchDir, func(path string, f os.FileInfo, _ error) error {
-		if f.IsDir() {
-			return nil
-		}
-		if strings.HasSuffix(strings.ToLower(path), "_test.go") || filepath.Ext(path) != ".go" {
-			return nil
-		}
-
-		// path = "../example/ginweb/handler/book/handler.go"
-		// pkgPath = "ginweb/handler/book"
-		var pkgPath string
-		{
-			relPath, err := filepath.Rel(searchDir, path) // "book/handler.go"
-			if err != nil {
-				return err
+	collected := make(map[*AstFileInfo]bool)
+	for _, searchDir := range searchDirs {
+		for _, pkg := range PackagesInDir(roots, searchDir) {
+			for _, astFile := range pkg.Syntax {
+				info := p.packages.files[astFile]
+				if collected[info] {
+					continue
+				}
+				collected[info] = true
+				if excluded(relPath(info.FileName), p.Exclude) {
+					log.Debug("忽略文件: %s", info.FileName)
+					continue
+				}
+				p.files = append(p.files, info)
 			}
-			pkgPath = filepath.ToSlash(filepath.Dir(filepath.Clean(filepath.Join(packageDir, relPath))))
 		}
+	}
+
+	// 收集路由注册代码中注册的接口
+	p.routes = collectRoutes(roots, p.RouteExtractors)
+	return nil
+}
 
-		astFile, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ParseComments)
-		if err != nil {
-			return fmt.Errorf("ParseFile error:%+v", err)
+// excluded 文件是否和任意一个 glob 格式匹配
+//
+// @param fileName 当前目录的相对路径，使用斜杆分隔
+func excluded(fileName string, patterns []string) bool {
+	segments := strings.Split(fileName, "/")
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		matchPath := strings.Contains(pattern, "/")
+		pattern = strings.TrimPrefix(pattern, "./")
+		for i := range segments {
+			name := segments[i]
+			if matchPath {
+				name = strings.Join(segments[:i+1], "/")
+			}
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
 		}
+	}
+	return false
+}
 
-		// absPath = "/Users/wanghai/gowork/github.com/whaios/goshowdoc/example/ginweb/handler/book/handler.go"
-		absPath, err := filepath.Abs(path)
-		if err != nil {
-			return err
+// relPath 当前目录的相对路径，使用斜杆分隔，保证不同机器上的结果一致
+func relPath(fileName string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, fileName); err == nil {
+			fileName = rel
 		}
-		fileInfo := p.packages.AddFile(pkgPath, absPath, astFile)
-		p.files = append(p.files, fileInfo)
-		return nil
-	})
+	}
+	return filepath.ToSlash(fileName)
 }
 
 // parseApiDoc 将Go源码文件中的注释解析为API文档
@@ -119,20 +175,48 @@ func (p *Parser) parseApiDoc(fileName string, astFile *ast.File) error {
 			if astDecl.Doc != nil && astDecl.Doc.List != nil {
 				log.Debug("解析方法注释: %s %s()", fileName, astDecl.Name.Name)
 				doc := newApiDoc(p, astFile, generalDoc)
+				// 使用注册的路由作为默认的 url，需要在解析参数之前确定请求方式
+				if route := p.FindRoute(astFile, astDecl); route != nil {
+					doc.setRoute(route)
+				}
 				// 逐行解析方法上的注释块
-				for _, comment := range astDecl.Doc.List {
+				comments := astDecl.Doc.List
+				if p.Dialect == DialectSwag {
+					comments = sortSwagComments(comments)
+				}
+				for _, comment := range comments {
 					log.Debug("	> 注释: %s", comment.Text)
 					if err := doc.ParseComment(astDecl.Name.Name, comment.Text); err != nil {
 						return fmt.Errorf("解析方法注释出错 %s %s():%+v", fileName, astDecl.Name.Name, err)
 					}
 				}
+				if p.InferTypes {
+					if err := p.inferTypes(doc, astFile, astDecl); err != nil {
+						return fmt.Errorf("推断请求和返回类型出错 %s %s():%+v", fileName, astDecl.Name.Name, err)
+					}
+				}
 				// 检查是否合法的API文档
 				if doc.Invalid() {
 					log.Debug("忽略方法注释（没有 title 或 url）: %s()", astDecl.Name.Name)
 					continue
 				}
+				if unknown := doc.checkPathVariables(); len(unknown) > 0 {
+					msg := fmt.Sprintf("路径参数 %s 不在 url %s 中", strings.Join(unknown, ", "), doc.Request.Url)
+					if p.StrictPathVars {
+						return fmt.Errorf("解析方法注释出错 %s %s():%s", fileName, astDecl.Name.Name, msg)
+					}
+					log.Warn("%s %s(): %s", fileName, astDecl.Name.Name, msg)
+				}
 
 				doc.Order = strconv.FormatInt(order, 10)
+				doc.Source = p.apiSource(fileName, astFile, astDecl)
+				if doc.Id == "" {
+					doc.Id = doc.DefaultId()
+				}
+				if other := p.findDoc(doc.Id); other != nil {
+					return fmt.Errorf("解析方法注释出错 %s %s():文档 id %s 和 %s:%d 重复",
+						fileName, astDecl.Name.Name, doc.Id, other.Source.File, other.Source.Line)
+				}
 				log.Info("生成文档(%d) %s", order, doc.Name())
 				p.Docs = append(p.Docs, doc)
 				order++
@@ -142,31 +226,90 @@ func (p *Parser) parseApiDoc(fileName string, astFile *ast.File) error {
 	return nil
 }
 
+// findDoc 查找指定 id 的文档
+func (p *Parser) findDoc(id string) *ApiDoc {
+	for _, doc := range p.Docs {
+		if doc.Id == id {
+			return doc
+		}
+	}
+	return nil
+}
+
+// apiSource 方法声明的源码位置，文件名使用当前目录的相对路径，保证不同机器上生成的中间格式一致
+func (p *Parser) apiSource(fileName string, astFile *ast.File, astDecl *ast.FuncDecl) ApiSource {
+	source := ApiSource{
+		File: relPath(fileName),
+		Line: p.packages.fset.Position(astDecl.Pos()).Line,
+		Func: astDecl.Name.Name,
+	}
+	if info := p.packages.files[astFile]; info != nil {
+		source.Package = info.PkgPath
+	}
+	if astDecl.Recv != nil && len(astDecl.Recv.List) > 0 {
+		if recv := recvTypeName(astDecl.Recv.List[0].Type); recv != "" {
+			source.Func = recv + "." + source.Func
+		}
+	}
+	return source
+}
+
+// recvTypeName 方法接收者的类型名称，去掉指针和泛型参数。如：*Handler[T] => Handler
+func recvTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return recvTypeName(t.X)
+	case *ast.IndexExpr:
+		return recvTypeName(t.X)
+	case *ast.IndexListExpr:
+		return recvTypeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// 参数位置对应的结构体标签，和 gin、echo 绑定参数时使用的标签一致
+var (
+	QueryTagKeys  = []string{"form", "query"}
+	FormTagKeys   = []string{"form"}
+	HeaderTagKeys = []string{"header"}
+	PathTagKeys   = []string{"uri", "param"}
+)
+
 // ParseObject 解析指定类型
-func (p *Parser) ParseObject(typeName string, file *ast.File) (*Object, error) {
+//
+// @param typeName 类型名称，支持泛型实例化，如：ListRsp、book.Book、Result[[]ListItem]
+// @param tagKeys 字段名使用的标签，如 QueryTagKeys，没有这些标签的字段使用 json 标签
+func (p *Parser) ParseObject(typeName string, file *ast.File, tagKeys ...string) (*Object, error) {
 	log.Debug("解析类型: %s", typeName)
-	typeSpecDef := p.packages.FindTypeSpec(typeName, file)
-	if typeSpecDef == nil {
+	t := p.packages.FindType(typeName, file)
+	if t == nil {
 		return nil, fmt.Errorf("没有找到类型定义: %s", typeName)
 	}
+	return p.parseObject(t, nil, tagKeys)
+}
 
-	// 解析结构体字段
-	st, ok := typeSpecDef.TypeSpec.Type.(*ast.StructType)
+// parseObject 解析结构体类型
+//
+// @param parents 正在解析的外层类型，避免无限循环解析递归类型
+// @param tagKeys 字段名使用的标签，为空时使用 json 标签
+func (p *Parser) parseObject(t types.Type, parents []types.Type, tagKeys []string) (*Object, error) {
+	st, ok := derefType(t).Underlying().(*types.Struct)
 	if !ok {
 		// 不是有效的类型，可能是自定义基础类型
 		return nil, nil
 	}
+	parents = append(parents, derefType(t))
 
 	obj := New()
-	for _, field := range st.Fields.List {
-		var name, jsonName, dataType, tag, comment string
-		var required bool
-
-		dataType = parseFieldType(field.Type)
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		fieldType := derefType(field.Type())
 
-		if len(field.Names) == 0 {
+		if field.Embedded() {
 			// 匿名字段
-			nObj, err := p.ParseObject(dataType, typeSpecDef.File)
+			nObj, err := p.parseObject(fieldType, parents, tagKeys)
 			if err != nil {
 				return nil, err
 			}
@@ -175,14 +318,16 @@ func (p *Parser) ParseObject(typeName string, file *ast.File) (*Object, error) {
 				obj.PutAnonymousObject(nObj)
 			}
 			continue
-		} else {
-			name = field.Names[0].Name
 		}
 
-		if field.Tag != nil {
-			// `json:"name" validate:"required"`
-			tag = field.Tag.Value
-			required = strings.Contains(tag, "required")
+		var jsonName, comment string
+		var required, isString bool
+		var rules validateRules
+		dataType := typeName(fieldType)
+		if tag := st.Tag(i); tag != "" {
+			// json:"name" validate:"required"
+			rules = parseValidateRules(tag)
+			required = rules.Required()
 
 			if jsonTag := getJsonTag(tag); jsonTag != "" {
 				var tagOpts tagOptions
@@ -190,153 +335,153 @@ func (p *Parser) ParseObject(typeName string, file *ast.File) (*Object, error) {
 				if tagOpts.Contains("string") {
 					// json 标签中定义了类型转换
 					dataType = "string"
+					isString = true
 				}
 			}
 
 			if jsonName == "" {
+				jsonName = field.Name()
+			}
+
+			if name, ok := lookupTagName(tag, tagKeys); ok {
+				// 参数位置对应的标签，如：form:"page"
 				jsonName = name
+				if jsonName == "" {
+					jsonName = field.Name()
+				}
+				dataType = typeName(fieldType)
+				isString = false
 			}
 		}
 		if jsonName == "" || jsonName == "-" {
 			continue
 		}
-		if field.Doc != nil {
-			// 字段上行的注释
-			// 忽略这种注释
-		}
-		if field.Comment != nil {
-			// 字段后面的同行注释
-			for _, comm := range field.Comment.List {
-				comment += strings.TrimSpace(strings.TrimLeft(comm.Text, "//"))
-			}
+		if astField := p.packages.FindField(field); astField != nil {
+			comment = declComment(astField.Comment, astField.Doc, field.Name())
 		}
 
 		objField := NewField(jsonName, dataType, required, comment)
+		objField.AddRemark(rules.Remark(dataType))
+		if !isString {
+			// 自定义类型的常量作为枚举值
+			enumType := fieldType
+			if slice, ok := fieldType.Underlying().(*types.Slice); ok {
+				enumType = derefType(slice.Elem())
+			}
+			objField.SetEnums(p.packages.FindEnums(enumType))
+		}
 
-		if isGolangPrimitiveType(dataType) ||
-			strings.HasPrefix(dataType, "map[") ||
-			dataType == "interface{}" ||
-			dataType == "" {
-			// 基础数据类型字段
+		if isString {
 			obj.PutField(objField)
-		} else if strings.HasPrefix(dataType, "[]") {
+			continue
+		}
+		switch ft := fieldType.Underlying().(type) {
+		case *types.Slice, *types.Array:
 			// 切片类型字段
-			itemType := strings.TrimLeft(dataType, "[]")
-			if isGolangPrimitiveType(itemType) {
-				obj.PutArray(objField)
-			} else if itemType == typeSpecDef.TypeSpec.Name.Name {
-				// 避免无限循环解析递归类型
+			itemType := derefType(ft.(interface{ Elem() types.Type }).Elem())
+			if !isStructType(itemType) || isParentType(itemType, parents) {
+				// 基础类型或递归类型，避免无限循环解析递归类型
 				obj.PutArray(objField)
-			} else {
-				nObj, err := p.ParseObject(itemType, typeSpecDef.File)
-				if err != nil {
-					return nil, err
-				}
-				if nObj == nil {
-					// 没有解析为有效类型，按普通字段处理
-					obj.PutArray(objField)
-				} else {
-					obj.PutObjectArray(objField, nObj)
-				}
+				continue
 			}
-		} else {
-			nObj, err := p.ParseObject(dataType, typeSpecDef.File)
+			nObj, err := p.parseObject(itemType, parents, tagKeys)
 			if err != nil {
 				return nil, err
 			}
-			if nObj == nil {
-				// 没有解析为有效类型，按普通字段处理
+			obj.PutObjectArray(objField, nObj)
+		case *types.Struct:
+			if isParentType(fieldType, parents) {
 				obj.PutField(objField)
-			} else {
-				obj.PutObject(objField, nObj)
+				continue
 			}
+			nObj, err := p.parseObject(fieldType, parents, tagKeys)
+			if err != nil {
+				return nil, err
+			}
+			obj.PutObject(objField, nObj)
+		default:
+			// 基础数据类型字段
+			obj.PutField(objField)
 		}
 	}
 	return obj, nil
 }
 
-// 获取指定目录的包名："./example/ginweb/handler" > "ginweb/handler"
-func dirToGoPkg(searchDir string) (string, error) {
-	cmd := exec.Command("go", "list", "-f={{.ImportPath}}")
-	cmd.Dir = searchDir
-	var stdout, stderr strings.Builder
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("execute go list command, %s, stdout:%s, stderr:%s", err, stdout.String(), stderr.String())
+// lookupTagName 按顺序查找标签中定义的字段名，标签值为空时使用字段名。如：form:"page,default=1" => page
+func lookupTagName(tag string, tagKeys []string) (string, bool) {
+	for _, key := range tagKeys {
+		if value, ok := reflect.StructTag(tag).Lookup(key); ok {
+			name, _ := parseJsonTag(value)
+			return name, true
+		}
 	}
+	return "", false
+}
 
-	outStr, _ := stdout.String(), stderr.String()
+// declComment 字段或常量的说明。
+// 优先使用后面的同行注释，没有时使用上方的文档注释，多行注释合并为一行。
+// 支持 // 和 /* */ 两种注释，文档注释开头的名称会被去掉，如：// Title 书名
+func declComment(trailing, doc *ast.CommentGroup, name string) string {
+	if comment := commentText(trailing); comment != "" {
+		return comment
+	}
+	comment := commentText(doc)
+	if rest := strings.TrimPrefix(comment, name+" "); rest != comment {
+		comment = strings.TrimSpace(rest)
+	}
+	return comment
+}
 
-	if outStr[0] == '_' { // will shown like _/{GOPATH}/src/{YOUR_PACKAGE} when NOT enable GO MODULE.
-		outStr = strings.TrimPrefix(outStr, "_"+build.Default.GOPATH+"/src/")
+// commentText 注释的内容，去掉注释符号，多行合并为一行
+func commentText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
 	}
-	f := strings.Split(outStr, "\n")
-	outStr = f[0]
-	return outStr, nil
+	return strings.Join(strings.Fields(group.Text()), " ")
 }
 
-func isGolangPrimitiveType(typeName string) bool {
-	switch typeName {
-	case "uint",
-		"int",
-		"uint8",
-		"int8",
-		"uint16",
-		"int16",
-		"byte",
-		"uint32",
-		"int32",
-		"rune",
-		"uint64",
-		"int64",
-		"float32",
-		"float64",
-		"bool",
-		"string":
-		return true
+func derefType(t types.Type) types.Type {
+	if ptr, ok := types.Unalias(t).(*types.Pointer); ok {
+		return derefType(ptr.Elem())
 	}
+	return types.Unalias(t)
+}
 
-	return false
+func isStructType(t types.Type) bool {
+	_, ok := t.Underlying().(*types.Struct)
+	return ok
 }
 
-func parseFieldType(expr ast.Expr) string {
-	if expr == nil {
-		return ""
-	}
-	switch expr.(type) {
-	case *ast.Ident:
-		id := expr.(*ast.Ident)
-		if id.Obj != nil && id.Obj.Decl != nil {
-			if ts, ok := id.Obj.Decl.(*ast.TypeSpec); ok {
-				// 自定义类型（可能是基础类型，也可能是struct，struct返回空字符串）
-				if _, ok = ts.Type.(*ast.Ident); ok {
-					return parseFieldType(ts.Type)
-				}
-			}
-		}
-		return id.Name
-	case *ast.ArrayType:
-		arrt := expr.(*ast.ArrayType)
-		return "[]" + parseFieldType(arrt.Elt)
-	case *ast.MapType:
-		mpt := expr.(*ast.MapType)
-		kn := parseFieldType(mpt.Key)
-		vn := parseFieldType(mpt.Value)
-		return fmt.Sprintf("map[%s]%s", kn, vn)
-	case *ast.SelectorExpr: // 包名.类型
-		selt := expr.(*ast.SelectorExpr)
-		pkgName := parseFieldType(selt.X)
-		if pkgName == "" {
-			return selt.Sel.Name
+func isParentType(t types.Type, parents []types.Type) bool {
+	for _, parent := range parents {
+		if types.Identical(t, parent) {
+			return true
 		}
-		return fmt.Sprintf("%s.%s", pkgName, selt.Sel.Name)
-	case *ast.StarExpr: // 指针
-		star := expr.(*ast.StarExpr)
-		return parseFieldType(star.X)
-	case *ast.InterfaceType:
+	}
+	return false
+}
+
+// typeName 字段类型名称，用于生成文档中的参数类型和模拟值。
+// 自定义基础类型返回底层类型名称，如：book.Id => int64
+func typeName(t types.Type) string {
+	t = derefType(t)
+	switch tt := t.(type) {
+	case *types.Basic:
+		return tt.Name()
+	case *types.Slice:
+		return "[]" + typeName(tt.Elem())
+	case *types.Array:
+		return "[]" + typeName(tt.Elem())
+	case *types.Map:
+		return fmt.Sprintf("map[%s]%s", typeName(tt.Key()), typeName(tt.Elem()))
+	case *types.Interface:
 		return "interface{}"
+	case *types.Named:
+		if _, ok := tt.Underlying().(*types.Struct); ok {
+			// 包名.类型名
+			return types.TypeString(tt, func(pkg *types.Package) string { return pkg.Name() })
+		}
+		return typeName(tt.Underlying())
 	}
-	return ""
+	return t.String()
 }
@@ -1,17 +1,22 @@
 package parser
 
 import (
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"strings"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/whaios/goshowdoc/log"
+	"github.com/whaios/goshowdoc/runapi"
 )
 
 var (
-	listDoc   = `{"Title":"获取书籍列表","Catalog":"测试文档/书籍","Description":"分页获取书籍列表","Remark":"","Order":"1","Request":{"Method":"get","Url":"{{BASEURL}}/api/v1/book/list","ApiStatus":"","Headers":[{"name":"Authorization","type":"string","require":"1","value":"bearer {{TOKEN}}","remark":"用户登录凭证"}],"PathVariable":[],"Query":[{"name":"page","type":"int","require":"1","value":"","remark":"第几页"},{"name":"page_size","type":"int","require":"1","value":"","remark":"每页显示条数"}],"ParamMode":"urlencoded","Params":[],"ParamJson":""},"Response":{"Example":"{\n    \"errcode\": 0,\n    \"errmsg\": \"错误说明\",\n    \"data\": {\n        \"total_count\": 0,\n        \"items\": [\n            {\n                \"id\": \"标识符\",\n                \"title\": \"书名\",\n                \"publisher\": \"出版社\",\n                \"tags\": [\n                    \"\"\n                ]\n            }\n        ]\n    }\n}","Params":[{"name":"errcode","type":"int","remark":"错误代码"},{"name":"errmsg","type":"string","remark":"错误说明"},{"name":"total_count","type":"int","remark":"总条数"},{"name":"items","type":"array","remark":"书籍"},{"name":"items.id","type":"string","remark":"标识符"},{"name":"items.title","type":"string","remark":"书名"},{"name":"items.publisher","type":"string","remark":"出版社"},{"name":"items.tags","type":"array","remark":"标签"}]},"ResponseFail":{"Example":"","Params":[]}}`
-	detailDoc = `{"Title":"获取指定书籍详情","Catalog":"测试文档/书籍","Description":"","Remark":"","Order":"2","Request":{"Method":"get","Url":"{{BASEURL}}/api/v1/book/detail/:id","ApiStatus":"","Headers":[{"name":"Authorization","type":"string","require":"1","value":"bearer {{TOKEN}}","remark":"用户登录凭证"}],"PathVariable":[{"name":"id","type":"int","require":"1","value":"","remark":"书籍 id"}],"Query":[],"ParamMode":"urlencoded","Params":[],"ParamJson":""},"Response":{"Example":"{\n    \"errcode\": 0,\n    \"errmsg\": \"错误说明\",\n    \"data\": {\n        \"id\": \"id\",\n        \"title\": \"书名\",\n        \"type\": \"包装：平装、精装\",\n        \"pages\": 0,\n        \"pub_date\": 0,\n        \"publisher\": \"出版社\",\n        \"isbn\": \"图书编号\",\n        \"is_active\": false,\n        \"desc\": \"介绍\",\n        \"pub_date_str\": \"出版日期\",\n        \"reviews\": [\n            {\n                \"id\": \"评论id\",\n                \"creation_unix\": 0,\n                \"book_id\": \"书籍id\",\n                \"content\": \"评论内容\",\n                \"review_user_id\": 0,\n                \"review_user_name\": \"评论人名称\",\n                \"recursive_reviews\": []\n            }\n        ],\n        \"review_page\": {\n            \"page\": 0,\n            \"page_size\": 0\n        }\n    }\n}","Params":[{"name":"errcode","type":"int","remark":"错误代码"},{"name":"errmsg","type":"string","remark":"错误说明"},{"name":"id","type":"string","remark":"id"},{"name":"title","type":"string","remark":"书名"},{"name":"type","type":"string","remark":"包装：平装、精装"},{"name":"pages","type":"int","remark":"页数"},{"name":"pub_date","type":"long","remark":"出版日期"},{"name":"publisher","type":"string","remark":"出版社"},{"name":"isbn","type":"string","remark":"图书编号"},{"name":"is_active","type":"boolean","remark":"是否激活"},{"name":"desc","type":"string","remark":"介绍"},{"name":"pub_date_str","type":"string","remark":"出版日期"},{"name":"reviews","type":"array","remark":"书籍评论"},{"name":"reviews.id","type":"object","remark":"评论id"},{"name":"reviews.creation_unix","type":"long","remark":"发表时间"},{"name":"reviews.book_id","type":"object","remark":"书籍id"},{"name":"reviews.content","type":"string","remark":"评论内容"},{"name":"reviews.review_user_id","type":"long","remark":"评论人id"},{"name":"reviews.review_user_name","type":"string","remark":"评论人名称"},{"name":"reviews.recursive_reviews","type":"array","remark":"测试是否能安全解析递归类型"},{"name":"review_page","type":"object","remark":"书籍评论分页"},{"name":"review_page.page","type":"int","remark":"第几页"},{"name":"review_page.page_size","type":"int","remark":"每页显示条数"}]},"ResponseFail":{"Example":"","Params":[]}}`
-	editDoc   = `{"Title":"新建或编辑书籍","Catalog":"测试文档/书籍/管理","Description":"","Remark":"","Order":"3","Request":{"Method":"post","Url":"{{BASEURL}}/api/v1/book/edit","ApiStatus":"","Headers":[{"name":"Authorization","type":"string","require":"1","value":"bearer {{TOKEN}}","remark":"用户登录凭证"}],"PathVariable":[],"Query":[],"ParamMode":"json","Params":[{"name":"id","type":"string","require":"0","value":"","remark":"id"},{"name":"title","type":"string","require":"1","value":"","remark":"书名"},{"name":"type","type":"string","require":"0","value":"","remark":"包装：平装、精装"},{"name":"pages","type":"int","require":"0","value":"0","remark":"页数"},{"name":"pub_date","type":"long","require":"0","value":"0","remark":"出版日期"},{"name":"publisher","type":"string","require":"0","value":"","remark":"出版社"},{"name":"isbn","type":"string","require":"0","value":"","remark":"图书编号"},{"name":"is_active","type":"boolean","require":"0","value":"false","remark":"是否激活"}],"ParamJson":"{\n    \"id\": \"id\",\n    \"title\": \"书名\",\n    \"type\": \"包装：平装、精装\",\n    \"pages\": 0,\n    \"pub_date\": 0,\n    \"publisher\": \"出版社\",\n    \"isbn\": \"图书编号\",\n    \"is_active\": false\n}"},"Response":{"Example":"{\n    \"errcode\": 0,\n    \"errmsg\": \"错误说明\"\n}","Params":[{"name":"errcode","type":"int","remark":"错误代码"},{"name":"errmsg","type":"string","remark":"错误说明"}]},"ResponseFail":{"Example":"","Params":[]}}`
-	delDoc    = `{"Title":"删除书籍","Catalog":"测试文档/书籍/管理","Description":"","Remark":"危险操作","Order":"4","Request":{"Method":"delete","Url":"{{BASEURL}}/api/v1/book/del/:id","ApiStatus":"","Headers":[{"name":"Authorization","type":"string","require":"1","value":"bearer {{TOKEN}}","remark":"用户登录凭证"}],"PathVariable":[{"name":"id","type":"int","require":"1","value":"","remark":"书籍 id"}],"Query":[],"ParamMode":"urlencoded","Params":[],"ParamJson":""},"Response":{"Example":"{\n    \"errcode\": 0,\n    \"errmsg\": \"错误说明\"\n}","Params":[{"name":"errcode","type":"int","remark":"错误代码"},{"name":"errmsg","type":"string","remark":"错误说明"}]},"ResponseFail":{"Example":"","Params":[]}}`
+	listDoc   = `{"id":"ginweb/handler/book.Handler.List","title":"获取书籍列表","catalog":"测试文档/书籍","description":"分页获取书籍列表","remark":"","order":"1","source":{"file":"../example/ginweb/handler/book/handler.go","line":28,"package":"ginweb/handler/book","func":"Handler.List"},"request":{"method":"get","url":"{{BASEURL}}/api/v1/book/list","api_status":"","headers":[{"name":"Authorization","type":"string","require":"1","value":"bearer {{TOKEN}}","remark":"用户登录凭证"}],"path_variable":[],"query":[{"name":"page","type":"int","require":"1","value":"","remark":"第几页"},{"name":"page_size","type":"int","require":"1","value":"","remark":"每页显示条数"}],"param_mode":"urlencoded","params":[],"param_json":""},"response":{"example":"{\n    \"errcode\": 0,\n    \"errmsg\": \"错误说明\",\n    \"data\": {\n        \"total_count\": 0,\n        \"items\": [\n            {\n                \"id\": \"标识符\",\n                \"title\": \"书名\",\n                \"publisher\": \"出版社\",\n                \"tags\": [\n                    \"\"\n                ]\n            }\n        ]\n    }\n}","params":[{"name":"errcode","type":"int","remark":"错误代码"},{"name":"errmsg","type":"string","remark":"错误说明"},{"name":"total_count","type":"int","remark":"总条数"},{"name":"items","type":"array","remark":"书籍"},{"name":"items.id","type":"string","remark":"标识符"},{"name":"items.title","type":"string","remark":"书名"},{"name":"items.publisher","type":"string","remark":"出版社"},{"name":"items.tags","type":"array","remark":"标签"}],"schema":{"allOf":[{"$ref":"#/components/schemas/comm.HttpCode"},{"type":"object","properties":{"data":{"$ref":"#/components/schemas/book.ListRsp"}}}]}},"response_fail":{"example":"","params":[]}}`
+	detailDoc = `{"id":"ginweb/handler/book.Handler.Detail","title":"获取指定书籍详情","catalog":"测试文档/书籍","description":"","remark":"","order":"2","source":{"file":"../example/ginweb/handler/book/handler.go","line":36,"package":"ginweb/handler/book","func":"Handler.Detail"},"request":{"method":"get","url":"{{BASEURL}}/api/v1/book/detail/:id","api_status":"","headers":[{"name":"Authorization","type":"string","require":"1","value":"bearer {{TOKEN}}","remark":"用户登录凭证"}],"path_variable":[{"name":"id","type":"int","require":"1","value":"","remark":"书籍 id"}],"query":[],"param_mode":"urlencoded","params":[],"param_json":""},"response":{"example":"{\n    \"errcode\": 0,\n    \"errmsg\": \"错误说明\",\n    \"data\": {\n        \"id\": \"id\",\n        \"title\": \"书名\",\n        \"type\": \"包装：平装、精装\",\n        \"pages\": 0,\n        \"pub_date\": 0,\n        \"publisher\": \"出版社\",\n        \"isbn\": \"图书编号\",\n        \"is_active\": false,\n        \"desc\": \"介绍\",\n        \"pub_date_str\": \"出版日期\",\n        \"reviews\": [\n            {\n                \"id\": 0,\n                \"creation_unix\": 0,\n                \"book_id\": 0,\n                \"content\": \"评论内容\",\n                \"review_user_id\": 0,\n                \"review_user_name\": \"评论人名称\",\n                \"recursive_reviews\": []\n            }\n        ],\n        \"review_page\": {\n            \"page\": 0,\n            \"page_size\": 0\n        }\n    }\n}","params":[{"name":"errcode","type":"int","remark":"错误代码"},{"name":"errmsg","type":"string","remark":"错误说明"},{"name":"id","type":"string","remark":"id"},{"name":"title","type":"string","remark":"书名"},{"name":"type","type":"string","remark":"包装：平装、精装"},{"name":"pages","type":"int","remark":"页数（最小值 1）"},{"name":"pub_date","type":"long","remark":"出版日期"},{"name":"publisher","type":"string","remark":"出版社"},{"name":"isbn","type":"string","remark":"图书编号"},{"name":"is_active","type":"boolean","remark":"是否激活"},{"name":"desc","type":"string","remark":"介绍"},{"name":"pub_date_str","type":"string","remark":"出版日期"},{"name":"reviews","type":"array","remark":"书籍评论"},{"name":"reviews.id","type":"long","remark":"评论id"},{"name":"reviews.creation_unix","type":"long","remark":"发表时间"},{"name":"reviews.book_id","type":"long","remark":"书籍id"},{"name":"reviews.content","type":"string","remark":"评论内容"},{"name":"reviews.review_user_id","type":"long","remark":"评论人id"},{"name":"reviews.review_user_name","type":"string","remark":"评论人名称"},{"name":"reviews.recursive_reviews","type":"array","remark":"测试是否能安全解析递归类型"},{"name":"review_page","type":"object","remark":"书籍评论分页"},{"name":"review_page.page","type":"int","remark":"第几页"},{"name":"review_page.page_size","type":"int","remark":"每页显示条数"}],"schema":{"allOf":[{"$ref":"#/components/schemas/comm.HttpCode"},{"type":"object","properties":{"data":{"$ref":"#/components/schemas/book.Detail"}}}]}},"response_fail":{"example":"","params":[]}}`
+	editDoc   = `{"id":"ginweb/handler/book.Handler.CreateOrUpdate","title":"新建或编辑书籍","catalog":"测试文档/书籍/管理","description":"","remark":"","order":"3","source":{"file":"../example/ginweb/handler/book/handler.go","line":44,"package":"ginweb/handler/book","func":"Handler.CreateOrUpdate"},"request":{"method":"post","url":"{{BASEURL}}/api/v1/book/edit","api_status":"","headers":[{"name":"Authorization","type":"string","require":"1","value":"bearer {{TOKEN}}","remark":"用户登录凭证"}],"path_variable":[],"query":[],"param_mode":"json","params":[{"name":"id","type":"string","require":"0","value":"","remark":"id"},{"name":"title","type":"string","require":"1","value":"","remark":"书名"},{"name":"type","type":"string","require":"0","value":"","remark":"包装：平装、精装"},{"name":"pages","type":"int","require":"0","value":"0","remark":"页数（最小值 1）"},{"name":"pub_date","type":"long","require":"0","value":"0","remark":"出版日期"},{"name":"publisher","type":"string","require":"0","value":"","remark":"出版社"},{"name":"isbn","type":"string","require":"0","value":"","remark":"图书编号"},{"name":"is_active","type":"boolean","require":"0","value":"false","remark":"是否激活"}],"param_json":"{\n    \"id\": \"id\",\n    \"title\": \"书名\",\n    \"type\": \"包装：平装、精装\",\n    \"pages\": 0,\n    \"pub_date\": 0,\n    \"publisher\": \"出版社\",\n    \"isbn\": \"图书编号\",\n    \"is_active\": false\n}","param_schema":{"$ref":"#/components/schemas/book.Book"}},"response":{"example":"{\n    \"errcode\": 0,\n    \"errmsg\": \"错误说明\"\n}","params":[{"name":"errcode","type":"int","remark":"错误代码"},{"name":"errmsg","type":"string","remark":"错误说明"}],"schema":{"$ref":"#/components/schemas/comm.HttpCode"}},"response_fail":{"example":"","params":[]}}`
+	delDoc    = `{"id":"ginweb/handler/book.Handler.Delete","title":"删除书籍","catalog":"测试文档/书籍/管理","description":"","remark":"危险操作","order":"4","source":{"file":"../example/ginweb/handler/book/handler.go","line":54,"package":"ginweb/handler/book","func":"Handler.Delete"},"request":{"method":"delete","url":"{{BASEURL}}/api/v1/book/del/:id","api_status":"","headers":[{"name":"Authorization","type":"string","require":"1","value":"bearer {{TOKEN}}","remark":"用户登录凭证"}],"path_variable":[{"name":"id","type":"int","require":"1","value":"","remark":"书籍 id"}],"query":[],"param_mode":"urlencoded","params":[],"param_json":""},"response":{"example":"{\n    \"errcode\": 0,\n    \"errmsg\": \"错误说明\"\n}","params":[{"name":"errcode","type":"int","remark":"错误代码"},{"name":"errmsg","type":"string","remark":"错误说明"}],"schema":{"$ref":"#/components/schemas/comm.HttpCode"}},"response_fail":{"example":"","params":[]}}`
 )
 
 func TestParseApiDoc(t *testing.T) {
@@ -19,6 +24,7 @@ func TestParseApiDoc(t *testing.T) {
 		dir := "../example/ginweb/handler"
 
 		p := NewParser()
+		p.RouteDirs = []string{"../example/ginweb/router"}
 		So(p.ParseApiDoc(dir), ShouldBeNil)
 		So(len(p.Docs), ShouldEqual, 4)
 
@@ -34,6 +40,186 @@ func TestParseApiDoc(t *testing.T) {
 	})
 }
 
+func TestParseApiDoc_Dirs(t *testing.T) {
+	Convey("测试解析多个目录，忽略匹配的文件", t, func() {
+		dirs := []string{"../example/ginweb/handler/book", "../example/ginweb/handler"}
+		p := NewParser()
+		p.RouteDirs = []string{"../example/ginweb/router"}
+		So(p.ParseApiDoc(dirs...), ShouldBeNil)
+		So(len(p.Docs), ShouldEqual, 4)
+
+		for _, exclude := range []string{"book", "*.go", "../example/ginweb/handler/book", "../example/ginweb/*/book/handler.go"} {
+			p = NewParser()
+			p.Exclude = []string{exclude}
+			So(p.ParseApiDoc(dirs...), ShouldBeNil)
+			So(len(p.Docs), ShouldEqual, 0)
+		}
+	})
+}
+
+func TestParser_FilterTags(t *testing.T) {
+	Convey("测试按标签过滤文档和 Schema 定义", t, func() {
+		p := NewParser()
+		p.RouteDirs = []string{"../example/ginweb/router"}
+		So(p.ParseApiDoc("../example/ginweb/handler"), ShouldBeNil)
+		So(p.Schemas, ShouldContainKey, "book.Detail")
+
+		p.Docs[0].Tags = []string{"public"}
+		p.FilterTags([]string{"public"}, nil)
+		So(len(p.Docs), ShouldEqual, 1)
+		So(p.Docs[0].Title, ShouldEqual, "获取书籍列表")
+		So(p.Schemas, ShouldContainKey, "comm.HttpCode")
+		So(p.Schemas, ShouldContainKey, "book.ListRsp")
+		So(p.Schemas, ShouldNotContainKey, "book.Detail")
+	})
+}
+
+func TestParseApiDoc_InferTypes(t *testing.T) {
+	Convey("测试从方法体中推断请求和返回类型", t, func() {
+		p := NewParser()
+		p.InferTypes = true
+		So(p.ParseApiDoc("testdata/infer"), ShouldBeNil)
+		So(len(p.Docs), ShouldEqual, 2)
+
+		save := p.Docs[0]
+		So(save.Title, ShouldEqual, "保存书籍")
+		So(save.Request.Params, ShouldResemble, []runapi.RequestParam{
+			runapi.NewRequestParam("title", "string", "1", "", "书名"),
+			runapi.NewRequestParam("pages", "int", "0", "0", "页数"),
+		})
+		So(save.Response.Params, ShouldResemble, []runapi.ResponseParam{
+			runapi.NewResponseParam("id", "string", "书籍 id"),
+		})
+		So(save.ResponseFail.Params, ShouldResemble, []runapi.ResponseParam{
+			runapi.NewResponseParam("code", "int", "错误代码"),
+			runapi.NewResponseParam("msg", "string", "错误说明"),
+		})
+
+		// 已经有 @resp 注释，不使用推断的返回类型
+		list := p.Docs[1]
+		So(list.Request.Query, ShouldResemble, []runapi.RequestParam{
+			runapi.NewRequestParam("page", "int", "0", "0", "第几页"),
+			runapi.NewRequestParam("page_size", "int", "0", "0", "每页显示条数"),
+		})
+		So(list.Response.Params, ShouldResemble, []runapi.ResponseParam{
+			{Name: "page", Type: "int", Remark: "第几页"},
+		})
+	})
+}
+
+func TestParseApiDoc_TagParams(t *testing.T) {
+	Convey("测试使用参数位置对应的标签解析结构体参数", t, func() {
+		p := NewParser()
+		So(p.ParseApiDoc("testdata/params"), ShouldBeNil)
+		So(len(p.Docs), ShouldEqual, 3)
+
+		list := p.Docs[0]
+		So(list.Request.Headers, ShouldResemble, []runapi.RequestParam{
+			runapi.NewHeaderParam("Authorization", "string", "1", "", "用户登录凭证"),
+			runapi.NewHeaderParam("X-Trace-Id", "string", "0", "", "请求 id"),
+		})
+		So(list.Request.Query, ShouldResemble, []runapi.RequestParam{
+			runapi.NewRequestParam("page", "int", "0", "0", "第几页"),
+			runapi.NewRequestParam("page_size", "int", "0", "0", "每页显示条数"),
+			runapi.NewRequestParam("keyword", "string", "0", "", "没有 form 标签时使用 json 标签"),
+		})
+
+		chapter := p.Docs[1]
+		So(chapter.Request.PathVariable, ShouldResemble, []runapi.RequestParam{
+			runapi.NewRequestParam("id", "int64", "1", "0", "书籍 id"),
+			runapi.NewRequestParam("chapter", "string", "0", "", "章节"),
+		})
+
+		// @param 不是新增的参数位置注释，仍然使用 json 标签
+		search := p.Docs[2]
+		So(search.Request.Query, ShouldResemble, []runapi.RequestParam{
+			runapi.NewRequestParam("page", "int", "0", "0", "第几页"),
+			runapi.NewRequestParam("pageSize", "int", "0", "0", "每页显示条数"),
+			runapi.NewRequestParam("keyword", "string", "0", "", "没有 form 标签时使用 json 标签"),
+			runapi.NewRequestParam("internal", "string", "0", "", "忽略"),
+		})
+	})
+}
+
+func TestParseApiDoc_Swag(t *testing.T) {
+	Convey("测试解析 swag 注释", t, func() {
+		p := NewParser()
+		p.Dialect = DialectSwag
+		So(p.ParseApiDoc("testdata/swag"), ShouldBeNil)
+		So(len(p.Docs), ShouldEqual, 3)
+
+		get := p.Docs[0]
+		So(get.Title, ShouldEqual, "获取书籍详情")
+		So(get.Description, ShouldEqual, "根据 id 获取书籍详情")
+		So(get.Catalog, ShouldEqual, "书籍")
+		So(get.Request.Method, ShouldEqual, runapi.MethodGet)
+		So(get.Request.Url, ShouldEqual, "{{BASEURL}}/books/:id")
+		So(get.Request.ParamMode, ShouldEqual, runapi.ParamModeUrlEncoded)
+		So(get.Request.PathVariable, ShouldResemble, []runapi.RequestParam{
+			runapi.NewRequestParam("id", "int", "true", "", "书籍 id"),
+		})
+		So(get.Request.Headers, ShouldResemble, []runapi.RequestParam{
+			runapi.NewHeaderParam("X-Trace-Id", "string", "false", "", "请求 id"),
+		})
+		So(get.Request.Query, ShouldResemble, []runapi.RequestParam{
+			runapi.NewRequestParam("lang", "string", "false", "zh", "语言"),
+		})
+		So(get.Response.Example, ShouldEqual, jsonFormat([]byte(`{"code":0,"msg":"错误说明","data":{"id":0,"title":"书名"}}`)))
+		So(get.Response.Params, ShouldResemble, []runapi.ResponseParam{
+			runapi.NewResponseParam("code", "int", "错误代码"),
+			runapi.NewResponseParam("msg", "string", "错误说明"),
+			runapi.NewResponseParam("data", "interface{}", "返回数据"),
+			runapi.NewResponseParam("data.id", "int64", "书籍 id"),
+			runapi.NewResponseParam("data.title", "string", "书名"),
+		})
+		So(len(get.ResponseFail.Params), ShouldEqual, 3)
+
+		save := p.Docs[1]
+		So(save.Request.Method, ShouldEqual, runapi.MethodPost)
+		So(save.Request.ParamMode, ShouldEqual, runapi.ParamModeJson)
+		So(save.Request.Params, ShouldResemble, []runapi.RequestParam{
+			runapi.NewRequestParam("title", "string", "1", "", "书名"),
+		})
+		So(save.Request.ParamJson, ShouldEqual, jsonFormat([]byte(`{"title":"书名"}`)))
+		So(save.Request.ApiStatus, ShouldEqual, "5")
+		So(save.Response.Example, ShouldEqual, jsonFormat([]byte(`[{"id":0,"title":"书名"}]`)))
+
+		list := p.Docs[2]
+		So(list.Catalog, ShouldEqual, "书籍")
+		So(list.Tags, ShouldResemble, []string{"书籍", "管理"})
+		So(list.Response.Example, ShouldEqual, jsonFormat([]byte(`{"code":0,"msg":"错误说明","data":{"total":0,"items":[{"id":0,"title":"书名"}]}}`)))
+		So(list.Response.Params, ShouldResemble, []runapi.ResponseParam{
+			runapi.NewResponseParam("code", "int", "错误代码"),
+			runapi.NewResponseParam("msg", "string", "错误说明"),
+			runapi.NewResponseParam("data", "interface{}", "返回数据"),
+			runapi.NewResponseParam("data.total", "int64", "总条数"),
+			runapi.NewResponseParam("data.items", "interface{}", "列表项"),
+			runapi.NewResponseParam("data.items.id", "int64", "书籍 id"),
+			runapi.NewResponseParam("data.items.title", "string", "书名"),
+		})
+		So(schemaJson(list.Response.Schema), ShouldEqual, `{"allOf":[{"allOf":[{"$ref":"#/components/schemas/swag.Result"},{"type":"object","properties":{"data":{"allOf":[{"$ref":"#/components/schemas/swag.Page"},{"type":"object","properties":{"items":{"type":"array","items":{"$ref":"#/components/schemas/swag.Book"}}}}]}}}]},{"type":"object","properties":{"code":{"type":"integer"}}}]}`)
+	})
+}
+
+func TestSplitSwagOverrides(t *testing.T) {
+	Convey("测试拆分 swag 替换的字段", t, func() {
+		So(splitSwagOverrides("data=Detail"), ShouldResemble, []string{"data=Detail"})
+		So(splitSwagOverrides("data=Page{items=[]Item},code=int"), ShouldResemble, []string{"data=Page{items=[]Item}", "code=int"})
+		So(splitSwagOverrides("data=Page{items=Pair{a=int,b=string},total=int}"), ShouldResemble, []string{"data=Page{items=Pair{a=int,b=string},total=int}"})
+	})
+}
+
+func TestSwagZeroJson(t *testing.T) {
+	Convey("测试 swag 基础类型的零值", t, func() {
+		So(string(swagZeroJson("int")), ShouldEqual, "0")
+		So(string(swagZeroJson("number")), ShouldEqual, "0")
+		So(string(swagZeroJson("boolean")), ShouldEqual, "false")
+		So(string(swagZeroJson("string")), ShouldEqual, `""`)
+		So(string(swagZeroJson("object")), ShouldEqual, "{}")
+		So(string(swagZeroJson("array")), ShouldEqual, "[]")
+	})
+}
+
 func TestParseObject_ListRsp(t *testing.T) {
 	Convey("测试解析对象", t, func() {
 		log.IsDebug = true
@@ -70,7 +256,188 @@ func TestParseObject_Detail(t *testing.T) {
 		for _, f := range obj.AllFields() {
 			Println("> "+typeName+":", f.Name, f.Type, f.Required, f.Value, f.Comment)
 		}
-		wantJson := `{"id":"id","title":"书名","type":"包装：平装、精装","pages":0,"pub_date":0,"publisher":"出版社","isbn":"图书编号","is_active":false,"desc":"介绍","pub_date_str":"出版日期","reviews":[{"id":"评论id","creation_unix":0,"book_id":"书籍id","content":"评论内容","review_user_id":0,"review_user_name":"评论人名称","recursive_reviews":[]}],"review_page":{"page":0,"page_size":0}}`
+		wantJson := `{"id":"id","title":"书名","type":"包装：平装、精装","pages":0,"pub_date":0,"publisher":"出版社","isbn":"图书编号","is_active":false,"desc":"介绍","pub_date_str":"出版日期","reviews":[{"id":0,"creation_unix":0,"book_id":0,"content":"评论内容","review_user_id":0,"review_user_name":"评论人名称","recursive_reviews":[]}],"review_page":{"page":0,"page_size":0}}`
 		So(string(obj.Json()), ShouldEqual, wantJson)
 	})
 }
+
+func TestParseObject_Generic(t *testing.T) {
+	Convey("测试解析泛型类型", t, func() {
+		searchDir := "../example/ginweb/handler"
+
+		p := NewParser()
+		So(p.collectGoFile(searchDir), ShouldBeNil)
+
+		var file *ast.File
+		for _, f := range p.files {
+			if strings.HasSuffix(f.FileName, "vo.go") {
+				file = f.File
+			}
+		}
+		So(file, ShouldNotBeNil)
+
+		cases := []struct {
+			TypeName string
+			WantJson string
+		}{
+			{"Result[[]ListItem]", `{"errcode":0,"errmsg":"错误说明","data":[{"id":"标识符","title":"书名","publisher":"出版社","tags":[""]}]}`},
+			{"Result[PageList[*ListItem]]", `{"errcode":0,"errmsg":"错误说明","data":{"total_count":0,"items":[{"id":"标识符","title":"书名","publisher":"出版社","tags":[""]}]}}`},
+			{"Result[int]", `{"errcode":0,"errmsg":"错误说明","data":0}`},
+			{"Summary", `{"latest":{"errcode":0,"errmsg":"错误说明","data":{"id":"标识符","title":"书名","publisher":"出版社","tags":[""]}},"counter":{"key":"键","value":0},"hot_tags":[{"name":"标签名"}]}`},
+		}
+		for _, c := range cases {
+			obj, err := p.ParseObject(c.TypeName, file)
+			So(err, ShouldBeNil)
+			So(obj, ShouldNotBeNil)
+			So(string(obj.Json()), ShouldEqual, c.WantJson)
+		}
+	})
+}
+
+func TestParseObject_Enums(t *testing.T) {
+	Convey("测试解析枚举值", t, func() {
+		p := NewParser()
+		So(p.collectGoFile("testdata/infer"), ShouldBeNil)
+
+		obj, err := p.ParseObject("infer.Order", nil)
+		So(err, ShouldBeNil)
+
+		var comments []string
+		for _, f := range obj.AllFields() {
+			comments = append(comments, f.Comment)
+		}
+		So(comments, ShouldResemble, []string{
+			"订单状态 1=新建, 2=已支付, 3=已关闭",
+			"支付渠道 alipay=支付宝, wechat=微信",
+			"历史状态 1=新建, 2=已支付, 3=已关闭",
+			"数量",
+		})
+		So(string(obj.Json()), ShouldEqual, `{"status":1,"channel":"alipay","history":[1],"quantity":0}`)
+	})
+}
+
+func TestFieldComment(t *testing.T) {
+	src := `package book
+
+type Book struct {
+	// Title 书名，
+	// 不能为空
+	Title string ` + "`json:\"title\"`" + ` // 书籍的名称
+	// Pages 页数
+	// 不包括封面
+	Pages int
+	/* 出版社 */
+	Publisher string
+	Isbn string /* 图书编号 */
+	//go:generate echo
+	Desc string
+}`
+	Convey("测试解析字段注释", t, func() {
+		file, err := goparser.ParseFile(token.NewFileSet(), "book.go", src, goparser.ParseComments)
+		So(err, ShouldBeNil)
+
+		var got []string
+		for _, field := range file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType).Fields.List {
+			got = append(got, declComment(field.Comment, field.Doc, field.Names[0].Name))
+		}
+		So(got, ShouldResemble, []string{"书籍的名称", "页数 不包括封面", "出版社", "图书编号", ""})
+	})
+}
+
+func TestPackages_FindType(t *testing.T) {
+	Convey("测试使用完整包名查找泛型类型", t, func() {
+		p := NewParser()
+		So(p.collectGoFile("../example/ginweb/handler"), ShouldBeNil)
+
+		So(p.packages.FindType("ginweb/comm.Page", nil).String(), ShouldEqual, "ginweb/comm.Page")
+		So(p.packages.FindType("ginweb/comm.KeyValue[string, []*int]", nil).String(), ShouldEqual, "ginweb/comm.KeyValue[string, []*int]")
+		So(p.packages.FindType("ginweb/comm.Missing", nil), ShouldBeNil)
+		So(p.packages.FindType("Page", nil), ShouldBeNil)
+	})
+}
+
+func TestCollectRoutes(t *testing.T) {
+	Convey("测试解析 gin 路由注册", t, func() {
+		p := NewParser()
+		p.RouteDirs = []string{"../example/ginweb/router"}
+		So(p.collectGoFile("../example/ginweb/handler"), ShouldBeNil)
+
+		got := make(map[string]string)
+		for handler, route := range p.routes {
+			got[handler.Name()] = route.Method + " " + route.Path
+		}
+		So(got, ShouldResemble, map[string]string{
+			"List":           "get /api/v1/book/list",
+			"Detail":         "get /api/v1/book/detail/:id",
+			"CreateOrUpdate": "post /api/v1/book/edit",
+			"Delete":         "delete /api/v1/book/del/:id",
+		})
+	})
+}
+
+func TestCollectRoutes_Extractors(t *testing.T) {
+	Convey("测试解析 net/http、chi、echo 和 gin 路由注册", t, func() {
+		p := NewParser()
+		So(p.collectGoFile("testdata/routes"), ShouldBeNil)
+
+		got := make(map[string]string)
+		for handler, route := range p.routes {
+			got[handler.Name()] = route.Method + " " + route.Path
+		}
+		So(got, ShouldResemble, map[string]string{
+			"ListBooks":    "get /books",
+			"GetBook":      "get /books/:id",
+			"CreateBook":   "post /books",
+			"ServeHTTP":    "get /healthz",
+			"GetAuthor":    "get /api/authors/:authorID",
+			"DeleteAuthor": "delete /api/authors/:authorID",
+			"ListFiles":    "get /files/*",
+			"GetReview":    "get /v2/reviews/:id",
+			"UpdateReview": "put /v2/reviews/:id",
+
+			"UpdatePublisher": "patch /publishers/:id",
+		})
+	})
+}
+
+func TestPathVariables(t *testing.T) {
+	cases := []struct {
+		Path  string
+		Names []string
+	}{
+		{"/books/{id}", []string{"id"}},
+		{"/authors/{authorID:[0-9]+}/books/:bookID", []string{"authorID", "bookID"}},
+		{"/files/{path...}", []string{"path"}},
+		{"/static/*filepath", []string{"filepath"}},
+		{"/files/*", nil},
+	}
+
+	Convey("测试获取路径参数", t, func() {
+		for _, c := range cases {
+			So(pathVariables(c.Path), ShouldResemble, c.Names)
+		}
+		So(normalizePath("/books/{$}"), ShouldEqual, "/books/")
+		So(normalizePath("/books/{id}"), ShouldEqual, "/books/:id")
+	})
+}
+
+func TestUrlPath(t *testing.T) {
+	cases := []struct {
+		Url  string
+		Path string
+	}{
+		{"{{BASEURL}}/api/v1/book/list", "/api/v1/book/list"},
+		{"{{BASEURL}}/api/v1/book/list?page=1", "/api/v1/book/list"},
+		{"http://127.0.0.1:8080/api/v1/book/list", "/api/v1/book/list"},
+		{"/api/v1/book/list", "/api/v1/book/list"},
+	}
+
+	Convey("测试获取 url 路径", t, func() {
+		for _, c := range cases {
+			So(urlPath(c.Url), ShouldEqual, c.Path)
+		}
+		So(joinPaths("", "/list"), ShouldEqual, "/list")
+		So(joinPaths("/api/v1", "book/"), ShouldEqual, "/api/v1/book/")
+		So(joinPaths(UnknownPath, "/list"), ShouldEqual, UnknownPath)
+	})
+}
@@ -0,0 +1,468 @@
+package parser
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/whaios/goshowdoc/log"
+	"github.com/whaios/goshowdoc/runapi"
+	"golang.org/x/tools/go/packages"
+)
+
+// Route 路由注册代码中注册的接口
+type Route struct {
+	Method  string      // 请求方式，和runapi保持一致使用小写
+	Path    string      // 请求路径，路径参数统一为 :name 格式，如：/api/v1/book/detail/:id
+	Handler *types.Func // 处理请求的方法
+}
+
+// RouteExtractor 路由框架的路由注册代码解析器。
+// 实现该接口并添加到 Parser.RouteExtractors 中可以支持更多的路由框架。
+type RouteExtractor interface {
+	// IsRouter 是否该框架的路由类型
+	IsRouter(t types.Type) bool
+	// Group 解析路由分组调用，返回分组的相对路径。如：r.Group("/api")。
+	// 相对路径不是常量时返回 UnknownPath
+	Group(pkg *packages.Package, call *ast.CallExpr) (relativePath string, ok bool)
+	// Register 解析路由注册调用。如：r.GET("/list", h.List)
+	Register(pkg *packages.Package, call *ast.CallExpr) (reg *RouteRegistration, ok bool)
+}
+
+// RouteRegistration 路由注册调用中注册的接口
+type RouteRegistration struct {
+	Method  string   // 请求方式，为空时使用 GET
+	Path    string   // 相对路径
+	Handler ast.Expr // 处理请求的表达式，如：h.List
+}
+
+// UnknownPath 不是常量的路由分组路径，分组中注册的接口无法确定完整路径，使用注释中的 @url
+const UnknownPath = "\x00"
+
+// DefaultRouteExtractors 默认支持的路由框架
+var DefaultRouteExtractors = []RouteExtractor{
+	GinRouteExtractor{},
+	HttpRouteExtractor{},
+	ChiRouteExtractor{},
+	EchoRouteExtractor{},
+}
+
+// collectRoutes 解析包中的路由注册代码，收集注册的接口。
+// 支持路由分组，以及通过方法参数传递的路由分组。
+func collectRoutes(pkgs []*packages.Package, extractors []RouteExtractor) map[*types.Func]*Route {
+	s := &routeScanner{
+		extractors: extractors,
+		funcs:      make(map[*types.Func]*funcDeclInfo),
+		called:     make(map[*types.Func]bool),
+		visiting:   make(map[*types.Func]bool),
+		routes:     make(map[*types.Func]*Route),
+		warned:     make(map[token.Pos]bool),
+	}
+	for _, pkg := range pkgs {
+		for _, astFile := range pkg.Syntax {
+			for _, decl := range astFile.Decls {
+				if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Body != nil {
+					if obj, ok := pkg.TypesInfo.Defs[funcDecl.Name].(*types.Func); ok {
+						s.funcs[obj] = &funcDeclInfo{decl: funcDecl, pkg: pkg}
+						s.order = append(s.order, obj)
+					}
+				}
+			}
+		}
+	}
+
+	// 参数中传入了路由的方法，在调用处解析，这样才能得到路由分组的路径前缀
+	for _, info := range s.funcs {
+		ast.Inspect(info.decl.Body, func(node ast.Node) bool {
+			if call, ok := node.(*ast.CallExpr); ok {
+				if callee := calleeFunc(info.pkg, call); callee != nil && s.funcs[callee] != nil {
+					for _, arg := range call.Args {
+						if s.isRouter(info.pkg.TypesInfo.TypeOf(arg)) {
+							s.called[callee] = true
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+	for _, obj := range s.order {
+		if !s.called[obj] {
+			s.scanFunc(s.funcs[obj], nil)
+		}
+	}
+	return s.routes
+}
+
+type funcDeclInfo struct {
+	decl *ast.FuncDecl
+	pkg  *packages.Package
+}
+
+type routeScanner struct {
+	extractors []RouteExtractor
+	funcs      map[*types.Func]*funcDeclInfo // 包中所有的方法
+	order      []*types.Func                 // 按源码顺序排列的方法，保证解析结果稳定
+	called     map[*types.Func]bool          // 参数中传入了路由的方法
+	visiting   map[*types.Func]bool          // 正在解析的方法，避免递归调用
+	routes     map[*types.Func]*Route        // key=处理请求的方法
+	warned     map[token.Pos]bool            // 已经输出警告的路由分组调用
+}
+
+func (s *routeScanner) isRouter(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	for _, ex := range s.extractors {
+		if ex.IsRouter(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanFunc 解析方法中的路由注册代码
+//
+// @param prefixes 方法参数中传入的路由分组路径前缀
+func (s *routeScanner) scanFunc(info *funcDeclInfo, prefixes map[types.Object]string) {
+	env := make(map[types.Object]string, len(prefixes))
+	for obj, prefix := range prefixes {
+		env[obj] = prefix
+	}
+
+	ast.Inspect(info.decl.Body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.AssignStmt:
+			// api := r.Group("/api")
+			if len(n.Lhs) == len(n.Rhs) {
+				for i, rhs := range n.Rhs {
+					s.bindPrefix(info.pkg, n.Lhs[i], rhs, env)
+				}
+			}
+		case *ast.ValueSpec:
+			// var api = r.Group("/api")
+			if len(n.Names) == len(n.Values) {
+				for i, value := range n.Values {
+					s.bindPrefix(info.pkg, n.Names[i], value, env)
+				}
+			}
+		case *ast.CallExpr:
+			s.scanCall(info.pkg, n, env)
+		}
+		return true
+	})
+}
+
+// bindPrefix 记录变量对应的路由分组路径前缀
+func (s *routeScanner) bindPrefix(pkg *packages.Package, lhs, rhs ast.Expr, env map[types.Object]string) {
+	id, ok := lhs.(*ast.Ident)
+	if !ok {
+		return
+	}
+	obj := pkg.TypesInfo.ObjectOf(id)
+	if obj == nil {
+		return
+	}
+	if prefix, ok := s.prefixOf(pkg, rhs, env); ok {
+		env[obj] = prefix
+	}
+}
+
+// prefixOf 路由分组的路径前缀
+func (s *routeScanner) prefixOf(pkg *packages.Package, expr ast.Expr, env map[types.Object]string) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return s.prefixOf(pkg, e.X, env)
+	case *ast.Ident:
+		if prefix, ok := env[pkg.TypesInfo.ObjectOf(e)]; ok {
+			return prefix, true
+		}
+	case *ast.CallExpr:
+		// r.Group("/api")
+		for _, ex := range s.extractors {
+			if relativePath, ok := ex.Group(pkg, e); ok {
+				return s.groupPrefix(pkg, e, relativePath, env), true
+			}
+		}
+	}
+	if s.isRouter(pkg.TypesInfo.TypeOf(expr)) {
+		// 没有分组的路由
+		return "", true
+	}
+	return "", false
+}
+
+// groupPrefix 路由分组调用的路径前缀，相对路径不是常量时输出警告并返回 UnknownPath
+func (s *routeScanner) groupPrefix(pkg *packages.Package, call *ast.CallExpr, relativePath string, env map[types.Object]string) string {
+	if relativePath == UnknownPath {
+		if !s.warned[call.Pos()] {
+			s.warned[call.Pos()] = true
+			log.Warn("路由分组的路径不是常量，分组中的接口使用注释中的 @url: %s %s", pkg.Fset.Position(call.Pos()), types.ExprString(call))
+		}
+		return UnknownPath
+	}
+	return joinPaths(s.receiverPrefix(pkg, call, env), relativePath)
+}
+
+// receiverPrefix 路由方法调用的路由对象的路径前缀，不是方法调用时为空
+func (s *routeScanner) receiverPrefix(pkg *packages.Package, call *ast.CallExpr, env map[types.Object]string) string {
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok && s.isRouter(pkg.TypesInfo.TypeOf(sel.X)) {
+		prefix, _ := s.prefixOf(pkg, sel.X, env)
+		return prefix
+	}
+	return ""
+}
+
+// scanCall 解析路由注册调用，或者传入了路由的方法调用
+func (s *routeScanner) scanCall(pkg *packages.Package, call *ast.CallExpr, env map[types.Object]string) {
+	for _, ex := range s.extractors {
+		if relativePath, ok := ex.Group(pkg, call); ok {
+			// 回调方式的路由分组，如 chi 的 r.Route("/api", func(r chi.Router) {...})
+			prefix := s.groupPrefix(pkg, call, relativePath, env)
+			for _, arg := range call.Args {
+				if fn, ok := arg.(*ast.FuncLit); ok {
+					for _, field := range fn.Type.Params.List {
+						for _, name := range field.Names {
+							if obj := pkg.TypesInfo.Defs[name]; obj != nil && s.isRouter(obj.Type()) {
+								env[obj] = prefix
+							}
+						}
+					}
+				}
+			}
+			return
+		}
+		if reg, ok := ex.Register(pkg, call); ok {
+			handler := handlerFunc(pkg, reg.Handler)
+			if handler == nil {
+				log.Debug("忽略路由注册（没有找到处理方法）: %s", types.ExprString(call))
+				return
+			}
+			method := strings.ToLower(reg.Method)
+			if method == "" {
+				method = runapi.MethodGet
+			}
+			routePath := joinPaths(s.receiverPrefix(pkg, call, env), reg.Path)
+			if routePath == UnknownPath {
+				log.Debug("忽略路由注册（路由分组的路径不是常量）: %s", types.ExprString(call))
+				return
+			}
+			s.addRoute(&Route{Method: method, Path: normalizePath(routePath), Handler: handler})
+			return
+		}
+	}
+
+	// 调用参数中传入了路由的方法，如：registerBook(api.Group("/book"))
+	callee := calleeFunc(pkg, call)
+	if callee == nil || !s.called[callee] || s.visiting[callee] {
+		return
+	}
+	info := s.funcs[callee]
+	prefixes := make(map[types.Object]string)
+	var params []*ast.Ident
+	for _, field := range info.decl.Type.Params.List {
+		params = append(params, field.Names...)
+	}
+	for i, arg := range call.Args {
+		if i >= len(params) {
+			break
+		}
+		if prefix, ok := s.prefixOf(pkg, arg, env); ok {
+			if obj := info.pkg.TypesInfo.Defs[params[i]]; obj != nil {
+				prefixes[obj] = prefix
+			}
+		}
+	}
+	s.visiting[callee] = true
+	s.scanFunc(info, prefixes)
+	s.visiting[callee] = false
+}
+
+func (s *routeScanner) addRoute(route *Route) {
+	if another, ok := s.routes[route.Handler]; ok {
+		log.Debug("方法 %s 注册了多个路由，使用 %s %s", route.Handler.FullName(), another.Method, another.Path)
+		return
+	}
+	log.Debug("收集路由: %s %s => %s", route.Method, route.Path, route.Handler.FullName())
+	s.routes[route.Handler] = route
+}
+
+// FindRoute 查找方法对应的路由
+func (p *Parser) FindRoute(astFile *ast.File, funcDecl *ast.FuncDecl) *Route {
+	info, ok := p.packages.files[astFile]
+	if !ok || info.Pkg.TypesInfo == nil {
+		return nil
+	}
+	obj, ok := info.Pkg.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+	if !ok {
+		return nil
+	}
+	return p.routes[obj]
+}
+
+// isNamedType 是否指定包中的指定类型
+func isNamedType(t types.Type, pkgPaths []string, names ...string) bool {
+	if t == nil {
+		return false
+	}
+	named, ok := derefType(t).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	return inStrings(named.Obj().Pkg().Path(), pkgPaths) && inStrings(named.Obj().Name(), names)
+}
+
+// routerMethod 路由对象的方法调用，返回方法名。如：r.GET("/list", h.List) => GET
+func routerMethod(pkg *packages.Package, call *ast.CallExpr, ex RouteExtractor) (string, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || !ex.IsRouter(pkg.TypesInfo.TypeOf(sel.X)) {
+		return "", false
+	}
+	return sel.Sel.Name, true
+}
+
+func inStrings(s string, list []string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// calleeFunc 被调用的方法
+func calleeFunc(pkg *packages.Package, call *ast.CallExpr) *types.Func {
+	var id *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return nil
+	}
+	if fn, ok := pkg.TypesInfo.Uses[id].(*types.Func); ok {
+		return fn.Origin()
+	}
+	return nil
+}
+
+// handlerFunc 处理请求的方法，如：h.List、listBooks、wrap(h.List)
+func handlerFunc(pkg *packages.Package, expr ast.Expr) *types.Func {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return handlerFunc(pkg, e.X)
+	case *ast.Ident:
+		if fn, ok := pkg.TypesInfo.Uses[e].(*types.Func); ok {
+			return fn.Origin()
+		}
+	case *ast.SelectorExpr:
+		if fn, ok := pkg.TypesInfo.Uses[e.Sel].(*types.Func); ok {
+			return fn.Origin()
+		}
+	case *ast.CallExpr:
+		// 包装的处理方法，如：http.HandlerFunc(h.List)
+		for i := len(e.Args) - 1; i >= 0; i-- {
+			if fn := handlerFunc(pkg, e.Args[i]); fn != nil {
+				return fn
+			}
+		}
+	}
+	// 实现了 http.Handler 的类型，忽略 http.HandlerFunc 等标准库中的类型
+	if t := pkg.TypesInfo.TypeOf(expr); t != nil {
+		if fn, ok := lookupMethod(t, "ServeHTTP"); ok && fn.Pkg() != nil && !inStrings(fn.Pkg().Path(), httpPkgPaths) {
+			return fn
+		}
+	}
+	return nil
+}
+
+func lookupMethod(t types.Type, name string) (*types.Func, bool) {
+	obj, _, _ := types.LookupFieldOrMethod(t, true, nil, name)
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return nil, false
+	}
+	return fn.Origin(), true
+}
+
+// groupPath 路由分组的相对路径，不是常量时返回 UnknownPath
+func groupPath(pkg *packages.Package, expr ast.Expr) string {
+	if relativePath, ok := stringValue(pkg, expr); ok {
+		return relativePath
+	}
+	return UnknownPath
+}
+
+// stringValue 字符串常量的值
+func stringValue(pkg *packages.Package, expr ast.Expr) (string, bool) {
+	tv, ok := pkg.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// joinPaths 拼接路由路径，和 gin 的处理方式一致保留结尾的 /。路径前缀为 UnknownPath 时返回 UnknownPath
+func joinPaths(absolutePath, relativePath string) string {
+	if absolutePath == UnknownPath {
+		return UnknownPath
+	}
+	if relativePath == "" {
+		return absolutePath
+	}
+	finalPath := path.Join("/", absolutePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(finalPath, "/") {
+		return finalPath + "/"
+	}
+	return finalPath
+}
+
+// urlPath 去掉 url 中的变量和域名，如：{{BASEURL}}/api/v1/book/list => /api/v1/book/list
+func urlPath(url string) string {
+	if i := strings.Index(url, "?"); i >= 0 {
+		url = url[:i]
+	}
+	if strings.HasPrefix(url, "{{") {
+		if i := strings.Index(url, "}}"); i >= 0 {
+			url = url[i+2:]
+		}
+	} else if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+		if j := strings.Index(url, "/"); j >= 0 {
+			url = url[j:]
+		} else {
+			url = "/"
+		}
+	}
+	return url
+}
+
+// isSameRoute 注释中的请求方式和url是否和注册的路由一致
+func isSameRoute(route *Route, method, url string) bool {
+	return route.Method == method && normalizePath(urlPath(url)) == route.Path
+}
+
+var bracePathVarPattern = regexp.MustCompile(`\{([^}:.]*)(?:\.\.\.)?(?::[^}]*)?\}`)
+
+// normalizePath 将路径参数统一为 :name 格式，和 RunApi 保持一致。
+// 如：/books/{id}、/books/{id:[0-9]+} => /books/:id；/files/{path...} => /files/:path；/books/{$} => /books/
+func normalizePath(routePath string) string {
+	routePath = strings.ReplaceAll(routePath, "{$}", "")
+	return bracePathVarPattern.ReplaceAllString(routePath, ":$1")
+}
+
+// pathVariables 路径中的参数名，支持 :name、{name} 和 *name 格式。
+func pathVariables(routePath string) []string {
+	var names []string
+	for _, segment := range strings.Split(normalizePath(routePath), "/") {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') {
+			names = append(names, segment[1:])
+		}
+	}
+	return names
+}
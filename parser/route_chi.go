@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/whaios/goshowdoc/log"
+	"golang.org/x/tools/go/packages"
+)
+
+var chiPkgPaths = []string{"github.com/go-chi/chi", "github.com/go-chi/chi/v5"}
+
+// ChiRouteExtractor 解析 chi 的路由注册代码。
+//
+//	r.Route("/api", func(r chi.Router) {
+//		r.Get("/authors/{authorID}", h.GetAuthor)
+//	})
+//	r.Method(http.MethodGet, "/files/*", http.HandlerFunc(h.ListFiles))
+//
+// Mount 挂载的子路由无法确定挂载路径，不解析。
+type ChiRouteExtractor struct{}
+
+func (ChiRouteExtractor) IsRouter(t types.Type) bool {
+	return isNamedType(t, chiPkgPaths, "Router", "Mux")
+}
+
+func (ex ChiRouteExtractor) Group(pkg *packages.Package, call *ast.CallExpr) (string, bool) {
+	name, ok := routerMethod(pkg, call, ex)
+	if !ok {
+		return "", false
+	}
+	switch name {
+	case "Route":
+		// r.Route("/api", func(r chi.Router) {...})
+		if len(call.Args) == 0 {
+			return "", false
+		}
+		return groupPath(pkg, call.Args[0]), true
+	case "Group", "With":
+		// 中间件分组，路径不变
+		return "", true
+	}
+	return "", false
+}
+
+func (ex ChiRouteExtractor) Register(pkg *packages.Package, call *ast.CallExpr) (*RouteRegistration, bool) {
+	name, ok := routerMethod(pkg, call, ex)
+	if !ok {
+		return nil, false
+	}
+	var method string
+	args := call.Args
+	switch name {
+	case "Get", "Post", "Put", "Delete", "Patch", "Head", "Options", "Connect", "Trace":
+		method = strings.ToUpper(name)
+	case "Method", "MethodFunc":
+		// r.Method("GET", "/list", h)
+		if len(args) == 0 {
+			return nil, false
+		}
+		if method, ok = stringValue(pkg, args[0]); !ok {
+			log.Debug("忽略路由注册（请求方式不是常量）: %s", types.ExprString(call))
+			return nil, false
+		}
+		args = args[1:]
+	case "Handle", "HandleFunc":
+		// 匹配所有请求方式，使用 GET
+	default:
+		return nil, false
+	}
+	if len(args) != 2 {
+		return nil, false
+	}
+	relativePath, ok := stringValue(pkg, args[0])
+	if !ok {
+		log.Debug("忽略路由注册（路径不是常量）: %s", types.ExprString(call))
+		return nil, false
+	}
+	return &RouteRegistration{Method: method, Path: relativePath, Handler: args[1]}, true
+}
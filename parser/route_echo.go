@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"go/ast"
+	"go/types"
+
+	"github.com/whaios/goshowdoc/log"
+	"golang.org/x/tools/go/packages"
+)
+
+var echoPkgPaths = []string{"github.com/labstack/echo", "github.com/labstack/echo/v4"}
+
+// EchoRouteExtractor 解析 echo 的路由注册代码。
+//
+//	g := e.Group("/v2")
+//	g.GET("/reviews/:id", h.GetReview, middleware.Logger())
+//	e.Add("PUT", "/v2/reviews/:id", h.UpdateReview)
+type EchoRouteExtractor struct{}
+
+func (EchoRouteExtractor) IsRouter(t types.Type) bool {
+	return isNamedType(t, echoPkgPaths, "Echo", "Group")
+}
+
+func (ex EchoRouteExtractor) Group(pkg *packages.Package, call *ast.CallExpr) (string, bool) {
+	name, ok := routerMethod(pkg, call, ex)
+	if !ok || name != "Group" || len(call.Args) == 0 {
+		return "", false
+	}
+	return groupPath(pkg, call.Args[0]), true
+}
+
+func (ex EchoRouteExtractor) Register(pkg *packages.Package, call *ast.CallExpr) (*RouteRegistration, bool) {
+	name, ok := routerMethod(pkg, call, ex)
+	if !ok {
+		return nil, false
+	}
+	var method string
+	args := call.Args
+	switch name {
+	case "GET", "POST", "PUT", "DELETE", "PATCH", "HEAD", "OPTIONS", "CONNECT", "TRACE":
+		method = name
+	case "Add":
+		// e.Add("GET", "/list", h.List)
+		if len(args) == 0 {
+			return nil, false
+		}
+		if method, ok = stringValue(pkg, args[0]); !ok {
+			log.Debug("忽略路由注册（请求方式不是常量）: %s", types.ExprString(call))
+			return nil, false
+		}
+		args = args[1:]
+	default:
+		return nil, false
+	}
+	if len(args) < 2 {
+		return nil, false
+	}
+	relativePath, ok := stringValue(pkg, args[0])
+	if !ok {
+		log.Debug("忽略路由注册（路径不是常量）: %s", types.ExprString(call))
+		return nil, false
+	}
+	// 路径后面是处理请求的方法，再后面是中间件
+	return &RouteRegistration{Method: method, Path: relativePath, Handler: args[1]}, true
+}
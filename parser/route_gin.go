@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/whaios/goshowdoc/log"
+	"golang.org/x/tools/go/packages"
+)
+
+var ginPkgPaths = []string{"github.com/gin-gonic/gin"}
+
+// GinRouteExtractor 解析 gin 的路由注册代码。
+//
+//	api := r.Group("/api/v1")
+//	api.GET("/book/list", h.List)
+//	api.Handle("POST", "/book/save", h.Save)
+type GinRouteExtractor struct{}
+
+func (GinRouteExtractor) IsRouter(t types.Type) bool {
+	return isNamedType(t, ginPkgPaths, "Engine", "RouterGroup", "IRouter", "IRoutes")
+}
+
+func (ex GinRouteExtractor) Group(pkg *packages.Package, call *ast.CallExpr) (string, bool) {
+	name, ok := routerMethod(pkg, call, ex)
+	if !ok || name != "Group" || len(call.Args) == 0 {
+		return "", false
+	}
+	return groupPath(pkg, call.Args[0]), true
+}
+
+func (ex GinRouteExtractor) Register(pkg *packages.Package, call *ast.CallExpr) (*RouteRegistration, bool) {
+	name, ok := routerMethod(pkg, call, ex)
+	if !ok {
+		return nil, false
+	}
+	var method string
+	args := call.Args
+	switch name {
+	case "GET", "POST", "PUT", "DELETE", "PATCH", "HEAD", "OPTIONS":
+		method = name
+	case "Handle":
+		// r.Handle("GET", "/list", h.List)
+		if len(args) == 0 {
+			return nil, false
+		}
+		if method, ok = stringValue(pkg, args[0]); !ok {
+			log.Debug("忽略路由注册（请求方式不是常量）: %s", types.ExprString(call))
+			return nil, false
+		}
+		args = args[1:]
+	default:
+		return nil, false
+	}
+	if len(args) < 2 {
+		return nil, false
+	}
+	relativePath, ok := stringValue(pkg, args[0])
+	if !ok {
+		log.Debug("忽略路由注册（路径不是常量）: %s", types.ExprString(call))
+		return nil, false
+	}
+	// 最后一个是处理请求的方法，前面的是中间件
+	return &RouteRegistration{Method: strings.ToUpper(method), Path: relativePath, Handler: args[len(args)-1]}, true
+}
@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/whaios/goshowdoc/log"
+	"golang.org/x/tools/go/packages"
+)
+
+var httpPkgPaths = []string{"net/http"}
+
+// HttpRouteExtractor 解析标准库 net/http 的路由注册代码，支持 Go 1.22 的路由模式。
+//
+//	mux.HandleFunc("GET /books/{id}", h.GetBook)
+//	mux.Handle("POST /books", http.HandlerFunc(h.CreateBook))
+//	http.HandleFunc("/healthz", healthz)
+//
+// 没有指定请求方式的路由使用 GET。
+type HttpRouteExtractor struct{}
+
+func (HttpRouteExtractor) IsRouter(t types.Type) bool {
+	return isNamedType(t, httpPkgPaths, "ServeMux")
+}
+
+func (HttpRouteExtractor) Group(pkg *packages.Package, call *ast.CallExpr) (string, bool) {
+	return "", false
+}
+
+func (ex HttpRouteExtractor) Register(pkg *packages.Package, call *ast.CallExpr) (*RouteRegistration, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || (sel.Sel.Name != "Handle" && sel.Sel.Name != "HandleFunc") || len(call.Args) != 2 {
+		return nil, false
+	}
+	fn, ok := pkg.TypesInfo.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || !inStrings(fn.Pkg().Path(), httpPkgPaths) {
+		return nil, false
+	}
+	// mux.Handle 或者包级别的 http.Handle
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil && !ex.IsRouter(recv.Type()) {
+		return nil, false
+	}
+	pattern, ok := stringValue(pkg, call.Args[0])
+	if !ok {
+		log.Debug("忽略路由注册（路径不是常量）: %s", types.ExprString(call))
+		return nil, false
+	}
+	method, routePath := splitHttpPattern(pattern)
+	return &RouteRegistration{Method: method, Path: routePath, Handler: call.Args[1]}, true
+}
+
+// splitHttpPattern 拆分 net/http 的路由模式。如：GET example.com/books/{id} => GET, /books/{id}
+func splitHttpPattern(pattern string) (method, routePath string) {
+	pattern = strings.TrimSpace(pattern)
+	if i := strings.IndexAny(pattern, " \t"); i >= 0 {
+		method = pattern[:i]
+		pattern = strings.TrimSpace(pattern[i+1:])
+	}
+	// 去掉域名
+	if i := strings.Index(pattern, "/"); i > 0 {
+		pattern = pattern[i:]
+	}
+	return method, pattern
+}
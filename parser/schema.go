@@ -0,0 +1,260 @@
+package parser
+
+import (
+	"go/constant"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SchemaRefPrefix 结构体类型的 Schema 引用前缀，和 OpenAPI 文档中 components 的位置一致
+const SchemaRefPrefix = "#/components/schemas/"
+
+// Schema 数据类型的 JSON Schema 描述，由 Go 类型生成，用于导出 OpenAPI 等文档。
+// 具名结构体类型生成为 Parser.Schemas 中的公共定义，字段通过 $ref 引用。
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// NewObjectSchema 创建对象类型的 Schema
+func NewObjectSchema() *Schema {
+	return &Schema{Type: "object", Properties: make(map[string]*Schema)}
+}
+
+// SetProperty 设置对象的属性
+func (s *Schema) SetProperty(name string, property *Schema, required bool) {
+	if s.Properties == nil {
+		s.Properties = make(map[string]*Schema)
+	}
+	s.Properties[name] = property
+	if required && !inStrings(name, s.Required) {
+		s.Required = append(s.Required, name)
+	}
+}
+
+// RefName 引用的公共定义名称，不是引用时返回空字符串
+func (s *Schema) RefName() string {
+	if !strings.HasPrefix(s.Ref, SchemaRefPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(s.Ref, SchemaRefPrefix)
+}
+
+// UsedSchemas roots 中直接或间接引用的 Schema 定义
+//
+// @param schemas 公共定义，即 Parser.Schemas
+func UsedSchemas(roots []*Schema, schemas map[string]*Schema) map[string]*Schema {
+	used := make(map[string]*Schema)
+	var visit func(s *Schema)
+	visit = func(s *Schema) {
+		if s == nil {
+			return
+		}
+		if name := s.RefName(); name != "" {
+			if _, ok := used[name]; !ok && schemas[name] != nil {
+				used[name] = schemas[name]
+				visit(schemas[name])
+			}
+		}
+		for _, property := range s.Properties {
+			visit(property)
+		}
+		visit(s.Items)
+		visit(s.AdditionalProperties)
+		for _, item := range s.AllOf {
+			visit(item)
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	return used
+}
+
+// withField 使用字段类型的 Schema 替换对象中的字段，如 swag 的 Result{data=Detail}。
+// 原来的 Schema 作为 allOf 的第一项。
+func (s *Schema) withField(name string, field *Schema) *Schema {
+	override := NewObjectSchema()
+	override.SetProperty(name, field, false)
+	return &Schema{AllOf: []*Schema{s, override}}
+}
+
+// schemaOf 生成类型的 Schema。具名结构体类型会添加到 Parser.Schemas 中，返回对它的引用。
+func (p *Parser) schemaOf(t types.Type) *Schema {
+	t = derefType(t)
+	switch tt := t.(type) {
+	case *types.Basic:
+		return basicSchema(tt)
+	case *types.Slice:
+		if basic, ok := tt.Elem().(*types.Basic); ok && basic.Kind() == types.Byte {
+			// encoding/json 将 []byte 编码为 base64 字符串
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: p.schemaOf(tt.Elem())}
+	case *types.Array:
+		return &Schema{Type: "array", Items: p.schemaOf(tt.Elem())}
+	case *types.Map:
+		return &Schema{Type: "object", AdditionalProperties: p.schemaOf(tt.Elem())}
+	case *types.Struct:
+		return p.structSchema(tt)
+	case *types.Named:
+		if isNamedType(tt, []string{"time"}, "Time") {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		if _, ok := tt.Underlying().(*types.Struct); ok {
+			return &Schema{Ref: SchemaRefPrefix + p.defineSchema(tt)}
+		}
+		schema := p.schemaOf(tt.Underlying())
+		for _, enum := range p.packages.FindEnums(tt) {
+			schema.Enum = append(schema.Enum, enum.Interface())
+		}
+		return schema
+	}
+	// interface{} 等任意类型
+	return &Schema{}
+}
+
+// defineSchema 添加结构体类型的 Schema 定义，返回定义名称。
+// 先登记名称再解析字段，递归类型的字段会引用自己。
+func (p *Parser) defineSchema(t *types.Named) string {
+	if name, ok := p.schemaNames.At(t).(string); ok {
+		return name
+	}
+	base := schemaName(t)
+	name := base
+	for i := 2; p.Schemas[name] != nil; i++ {
+		// 不同的包可能有相同的包名和类型名
+		name = base + "_" + strconv.Itoa(i)
+	}
+	p.schemaNames.Set(t, name)
+	p.Schemas[name] = &Schema{}
+	*p.Schemas[name] = *p.structSchema(t.Underlying().(*types.Struct))
+	return name
+}
+
+// structSchema 结构体的 Schema，字段名和 encoding/json 的规则一致，使用 json 标签
+func (p *Parser) structSchema(st *types.Struct) *Schema {
+	schema := NewObjectSchema()
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		tag := st.Tag(i)
+		jsonName, tagOpts := parseJsonTag(getJsonTag(tag))
+		if jsonName == "-" && tagOpts == "" {
+			continue
+		}
+		if field.Embedded() && jsonName == "" {
+			// 匿名结构体字段的字段提升到外层
+			if embedded, ok := derefType(field.Type()).Underlying().(*types.Struct); ok {
+				inner := p.structSchema(embedded)
+				for name, property := range inner.Properties {
+					schema.SetProperty(name, property, inStrings(name, inner.Required))
+				}
+				continue
+			}
+		}
+		if !field.Exported() {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = field.Name()
+		}
+
+		property := p.schemaOf(field.Type())
+		if tagOpts.Contains("string") {
+			// json 标签中定义了类型转换
+			property = &Schema{Type: "string"}
+		}
+		if astField := p.packages.FindField(field); astField != nil {
+			property.Description = declComment(astField.Comment, astField.Doc, field.Name())
+		}
+		rules := parseValidateRules(tag)
+		rules.applySchema(property)
+		schema.SetProperty(jsonName, property, rules.Required())
+	}
+	return schema
+}
+
+func basicSchema(t *types.Basic) *Schema {
+	switch t.Kind() {
+	case types.Bool, types.UntypedBool:
+		return &Schema{Type: "boolean"}
+	case types.Int8, types.Int16, types.Int32, types.Uint8, types.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case types.Int, types.Int64, types.Uint, types.Uint32, types.Uint64, types.UntypedInt, types.UntypedRune:
+		return &Schema{Type: "integer", Format: "int64"}
+	case types.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case types.Float64, types.UntypedFloat:
+		return &Schema{Type: "number", Format: "double"}
+	case types.String, types.UntypedString:
+		return &Schema{Type: "string"}
+	}
+	return &Schema{}
+}
+
+var schemaNamePattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// schemaName 结构体类型的定义名称，如：book.Book、comm.Result_array_book.ListItem
+func schemaName(t types.Type) string {
+	return schemaNamePattern.ReplaceAllString(schemaTypeName(t), "_")
+}
+
+func schemaTypeName(t types.Type) string {
+	t = derefType(t)
+	switch tt := t.(type) {
+	case *types.Named:
+		name := tt.Obj().Name()
+		if pkg := tt.Obj().Pkg(); pkg != nil {
+			name = pkg.Name() + "." + name
+		}
+		for i := 0; i < tt.TypeArgs().Len(); i++ {
+			name += "_" + schemaTypeName(tt.TypeArgs().At(i))
+		}
+		return name
+	case *types.Slice:
+		return "array_" + schemaTypeName(tt.Elem())
+	case *types.Array:
+		return "array_" + schemaTypeName(tt.Elem())
+	case *types.Map:
+		return "map_" + schemaTypeName(tt.Key()) + "_" + schemaTypeName(tt.Elem())
+	case *types.Basic:
+		return tt.Name()
+	}
+	return "any"
+}
+
+// Interface 常量值对应的 Go 值，用于 Schema 的 enum
+func (e *EnumValue) Interface() interface{} {
+	switch e.Value.Kind() {
+	case constant.Bool:
+		return constant.BoolVal(e.Value)
+	case constant.String:
+		return constant.StringVal(e.Value)
+	case constant.Int:
+		if v, ok := constant.Int64Val(e.Value); ok {
+			return v
+		}
+	case constant.Float:
+		v, _ := constant.Float64Val(e.Value)
+		return v
+	}
+	return e.Value.ExactString()
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"encoding/json"
+	"go/ast"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func schemaJson(schema *Schema) string {
+	data, _ := json.Marshal(schema)
+	return string(data)
+}
+
+func TestSchemaOf_Generic(t *testing.T) {
+	Convey("测试由泛型类型生成 Schema 定义", t, func() {
+		p := NewParser()
+		So(p.collectGoFile("../example/ginweb/handler"), ShouldBeNil)
+
+		var file *ast.File
+		for _, f := range p.files {
+			if strings.HasSuffix(f.FileName, "vo.go") {
+				file = f.File
+			}
+		}
+		So(file, ShouldNotBeNil)
+
+		schema := p.schemaOf(p.packages.FindType("Result[[]ListItem]", file))
+		So(schemaJson(schema), ShouldEqual, `{"$ref":"#/components/schemas/comm.Result_array_book.ListItem"}`)
+		So(schemaJson(p.Schemas["comm.Result_array_book.ListItem"]), ShouldEqual,
+			`{"type":"object","properties":{"data":{"type":"array","description":"返回数据","items":{"$ref":"#/components/schemas/book.ListItem"}},"errcode":{"type":"integer","format":"int64","description":"错误代码"},"errmsg":{"type":"string","description":"错误说明"}}}`)
+		So(schemaJson(p.Schemas["book.ListItem"]), ShouldEqual,
+			`{"type":"object","properties":{"id":{"type":"string","description":"标识符"},"publisher":{"type":"string","description":"出版社"},"tags":{"type":"array","description":"标签","items":{"type":"string"}},"title":{"type":"string","description":"书名"}}}`)
+	})
+}
+
+func TestSchemaOf_Enums(t *testing.T) {
+	Convey("测试由枚举类型和递归类型生成 Schema", t, func() {
+		p := NewParser()
+		So(p.collectGoFile("testdata/infer"), ShouldBeNil)
+
+		schema := p.schemaOf(p.packages.FindType("infer.Order", nil))
+		So(schema.RefName(), ShouldEqual, "infer.Order")
+		order := p.Schemas["infer.Order"]
+		So(schemaJson(order.Properties["status"]), ShouldEqual, `{"type":"integer","format":"int64","description":"订单状态","enum":[1,2,3]}`)
+		So(schemaJson(order.Properties["channel"]), ShouldEqual, `{"type":"string","description":"支付渠道","enum":["alipay","wechat"]}`)
+		So(schemaJson(order.Properties["history"].Items), ShouldEqual, `{"type":"integer","format":"int64","enum":[1,2,3]}`)
+	})
+}
+
+func TestValidateRules_ApplySchema(t *testing.T) {
+	Convey("测试校验规则转换为 Schema 的约束", t, func() {
+		cases := []struct {
+			Tag    string
+			Type   string
+			Schema string
+		}{
+			{`binding:"required,min=1,max=20"`, "string", `{"type":"string","minLength":1,"maxLength":20}`},
+			{`validate:"gte=1,lt=100"`, "integer", `{"type":"integer","minimum":1,"exclusiveMaximum":100}`},
+			{`validate:"len=3"`, "array", `{"type":"array","minItems":3,"maxItems":3}`},
+			{`validate:"oneof=red green"`, "string", `{"type":"string","enum":["red","green"]}`},
+			{`validate:"email"`, "string", `{"type":"string","format":"email"}`},
+		}
+		for _, c := range cases {
+			schema := &Schema{Type: c.Type}
+			parseValidateRules(c.Tag).applySchema(schema)
+			So(schemaJson(schema), ShouldEqual, c.Schema)
+		}
+	})
+}
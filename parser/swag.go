@@ -0,0 +1,400 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/sjson"
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+// Dialect 注释的语法
+type Dialect string
+
+const (
+	DialectShowDoc Dialect = "showdoc" // 默认语法，如：@title、@url
+	DialectSwag    Dialect = "swag"    // 兼容 swaggo/swag 的注释，如：@Summary、@Router
+)
+
+// ParseDialect 解析注释语法的名称，为空时使用默认语法
+func ParseDialect(name string) (Dialect, error) {
+	switch Dialect(strings.ToLower(name)) {
+	case "", DialectShowDoc:
+		return DialectShowDoc, nil
+	case DialectSwag, "swaggo":
+		return DialectSwag, nil
+	}
+	return "", fmt.Errorf("不支持的注释语法: %s", name)
+}
+
+// sortSwagComments 调整 swag 注释的解析顺序。
+// swag 的 @Router 通常写在最后，需要先确定请求方式和 @Accept 的参数格式，再解析参数。
+func sortSwagComments(comments []*ast.Comment) []*ast.Comment {
+	priority := func(comment *ast.Comment) int {
+		fields := strings.Fields(strings.TrimLeft(comment.Text, "/"))
+		if len(fields) == 0 {
+			return 2
+		}
+		switch strings.ToLower(fields[0]) {
+		case "@router":
+			return 0
+		case "@accept":
+			return 1
+		}
+		return 2
+	}
+	sorted := make([]*ast.Comment, len(comments))
+	copy(sorted, comments)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return priority(sorted[i]) < priority(sorted[j])
+	})
+	return sorted
+}
+
+// parseSwagComment 解析 swag 语法的单行注释
+//
+//	// ShowBook godoc
+//	// @Summary     获取书籍详情
+//	// @Description 根据 id 获取书籍详情
+//	// @Tags        书籍
+//	// @Accept      json
+//	// @Param       id   path     int  true  "书籍 id"
+//	// @Success     200  {object} comm.Result{data=Detail}
+//	// @Failure     400  {object} comm.HttpCode
+//	// @Router      /books/{id} [get]
+func (p *ApiDoc) parseSwagComment(funcName, attribute, lineRemainder string) error {
+	switch attribute {
+	case funcName:
+		// swag 的方法注释第一行通常是 “方法名 godoc”，只在没有 @Summary 时作为标题
+		if p.Title == "" && lineRemainder != "godoc" {
+			p.Title = lineRemainder
+		}
+	case "@summary":
+		p.Title = lineRemainder
+	case "@id":
+		p.Id = lineRemainder
+	case "@description":
+		p.parseDescriptionComment(lineRemainder)
+	case "@tags":
+		// 第一个标签作为文档目录，有多个 @Tags 时只使用第一个。所有标签作为文档的标签
+		if tag := strings.TrimSpace(strings.Split(lineRemainder, ",")[0]); tag != "" && !p.annotated["@tags"] {
+			p.annotated["@tags"] = true
+			p.parseCatalogComment(tag)
+		}
+		p.parseTagsComment(lineRemainder)
+	case "@accept":
+		return p.parseSwagAcceptComment(lineRemainder)
+	case "@param":
+		return p.parseSwagParamComment(lineRemainder)
+	case "@success":
+		return p.parseSwagResponseComment(lineRemainder, false)
+	case "@failure":
+		return p.parseSwagResponseComment(lineRemainder, true)
+	case "@router":
+		return p.parseSwagRouterComment(lineRemainder)
+	case "@deprecated":
+		p.Request.ApiStatus = runapi.TransToApiStatus("5")
+	}
+	return nil
+}
+
+// parseSwagAcceptComment 解析请求参数格式，如：json、x-www-form-urlencoded、mpfd
+func (p *ApiDoc) parseSwagAcceptComment(commentLine string) error {
+	accept := strings.TrimSpace(strings.Split(commentLine, ",")[0])
+	switch accept {
+	case "json", "application/json":
+		if p.Request.Method != runapi.MethodGet {
+			p.Request.ParamMode = runapi.ParamModeJson
+		}
+	case "x-www-form-urlencoded", "application/x-www-form-urlencoded":
+		p.Request.ParamMode = runapi.ParamModeUrlEncoded
+	case "mpfd", "multipart/form-data":
+		if p.Request.Method != runapi.MethodGet {
+			p.Request.ParamMode = runapi.ParamModeFormData
+		}
+	}
+	return nil
+}
+
+var swagRouterPattern = regexp.MustCompile(`^(\S+)\s+\[(\w+)]`)
+
+// parseSwagRouterComment 解析接口地址，如：/books/{id} [get]
+func (p *ApiDoc) parseSwagRouterComment(commentLine string) error {
+	matches := swagRouterPattern.FindStringSubmatch(commentLine)
+	if len(matches) != 3 {
+		return fmt.Errorf("无法解析 @Router 注释 \"%s\"\n不符合格式 [路径] [[请求方式]]", commentLine)
+	}
+	// swag 的接口地址是相对路径，使用 {{BASEURL}} 作为前缀
+	return p.parseUrlComment(matches[2] + " " + BaseUrlVar + normalizePath(matches[1]))
+}
+
+var swagParamPattern = regexp.MustCompile(`^(\S+)\s+(\w+)\s+(\S+)\s+(\w+)\s+"([^"]*)"(.*)$`)
+var swagDefaultPattern = regexp.MustCompile(`default\(([^)]*)\)`)
+
+// parseSwagParamComment 解析请求参数
+//
+// 如：id path int true "书籍 id" default(1)，格式为 [参数名] [位置] [类型] [必填] ["备注"] [属性]
+func (p *ApiDoc) parseSwagParamComment(commentLine string) error {
+	matches := swagParamPattern.FindStringSubmatch(commentLine)
+	if len(matches) != 7 {
+		return fmt.Errorf("无法解析 @Param 注释 \"%s\"\n不符合格式 [参数名] [位置] [类型] [必填] [\"备注\"]", commentLine)
+	}
+	name, in, dataType, required, remark := matches[1], matches[2], matches[3], matches[4], matches[5]
+	var value string
+	if m := swagDefaultPattern.FindStringSubmatch(matches[6]); len(m) == 2 {
+		value = m[1]
+	}
+
+	if !isSwagPrimitiveType(dataType) {
+		// 结构体参数，使用参数位置对应的标签
+		switch in {
+		case "body":
+			p.annotated["@param"] = true
+			params, paramJson, err := p.parseRequestParam(dataType+"{}", nil)
+			if err != nil {
+				return err
+			}
+			p.addParams(params, paramJson)
+			p.addParamSchema(p.schemaOfComment(dataType + "{}"))
+		case "query":
+			p.annotated["@query"] = true
+			return p.parseQueryComment(dataType + "{}")
+		case "path":
+			return p.parsePathVarComment(dataType + "{}")
+		case "header":
+			return p.parseHeaderComment(dataType + "{}")
+		case "formData":
+			p.annotated["@param"] = true
+			params, paramJson, err := p.parseRequestParam(dataType+"{}", FormTagKeys)
+			if err != nil {
+				return err
+			}
+			p.addParams(params, paramJson)
+		}
+		return nil
+	}
+
+	dataType = swagDataType(dataType)
+	switch in {
+	case "query":
+		p.annotated["@query"] = true
+		p.Request.Query = append(p.Request.Query, runapi.NewRequestParam(name, dataType, required, value, remark))
+	case "path":
+		p.Request.PathVariable = append(p.Request.PathVariable, runapi.NewRequestParam(name, dataType, required, value, remark))
+	case "header":
+		p.Request.Headers = append(p.Request.Headers, runapi.NewHeaderParam(name, dataType, required, value, remark))
+	case "formData", "body":
+		p.annotated["@param"] = true
+		p.addParams([]runapi.RequestParam{runapi.NewRequestParam(name, dataType, required, value, remark)}, "")
+	}
+	return nil
+}
+
+// isSwagPrimitiveType 是否 swag 的基础数据类型
+func isSwagPrimitiveType(dataType string) bool {
+	switch strings.TrimPrefix(dataType, "[]") {
+	case "string", "integer", "number", "boolean", "file", "int", "bool", "object", "array",
+		"int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+		return true
+	}
+	return false
+}
+
+// swagDataType 将 swag 的数据类型转换为 Go 的数据类型
+func swagDataType(dataType string) string {
+	switch dataType {
+	case "integer":
+		return "int"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "file":
+		return "string"
+	case "array":
+		return "[]string"
+	}
+	return dataType
+}
+
+var swagResponsePattern = regexp.MustCompile(`^(\d+|default)\s+\{(\w+)}\s+(\S+)`)
+
+// parseSwagResponseComment 解析返回内容，只使用第一个 @Success 和 @Failure 注释
+//
+// 如：200 {object} comm.Result{data=Detail}，格式为 [状态码] {[类型]} [数据类型]
+func (p *ApiDoc) parseSwagResponseComment(commentLine string, fail bool) error {
+	attribute, resp := "@resp", &p.Response
+	if fail {
+		attribute, resp = "@resp_fail", &p.ResponseFail
+	}
+	if p.annotated[attribute] {
+		return nil
+	}
+	matches := swagResponsePattern.FindStringSubmatch(commentLine)
+	if len(matches) != 4 {
+		// 只有状态码的返回，如：@Success 204
+		return nil
+	}
+	if matches[2] != "object" && matches[2] != "array" {
+		// 基础数据类型的返回，如：{string} string
+		return nil
+	}
+	p.annotated[attribute] = true
+
+	params, paramJson, err := p.parseSwagResponseType(matches[3])
+	if err != nil {
+		return err
+	}
+	schema := p.swagResponseSchema(matches[3])
+	if matches[2] == "array" {
+		paramJson = append(append([]byte("["), paramJson...), ']')
+		if schema != nil {
+			schema = &Schema{Type: "array", Items: schema}
+		}
+	}
+	resp.addParams(params, paramJson)
+	resp.addSchema(schema)
+	return nil
+}
+
+// splitSwagResponseType 拆分返回的数据类型和替换的字段。如：comm.Result{data=Detail} => comm.Result, data=Detail
+func splitSwagResponseType(dataType string) (baseType, overrides string) {
+	if i := strings.Index(dataType, "{"); i > 0 && strings.HasSuffix(dataType, "}") {
+		return dataType[:i], dataType[i+1 : len(dataType)-1]
+	}
+	return dataType, ""
+}
+
+// splitSwagOverrides 拆分替换的字段，忽略嵌套的替换字段中的逗号。
+// 如：data=Page{items=[]Item},code=int => data=Page{items=[]Item}, code=int
+func splitSwagOverrides(overrides string) []string {
+	var fields []string
+	var depth, begin int
+	for i := 0; i < len(overrides); i++ {
+		switch overrides[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				fields = append(fields, overrides[begin:i])
+				begin = i + 1
+			}
+		}
+	}
+	return append(fields, overrides[begin:])
+}
+
+// swagResponseSchema 返回的数据类型的 Schema，替换的字段使用 allOf 合并
+func (p *ApiDoc) swagResponseSchema(dataType string) *Schema {
+	baseType, overrides := splitSwagResponseType(dataType)
+	schema := p.schemaOfComment(baseType + "{}")
+	if schema == nil || overrides == "" {
+		return schema
+	}
+	for _, override := range splitSwagOverrides(overrides) {
+		kv := strings.SplitN(override, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		field, fieldType := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		isArray := strings.HasPrefix(fieldType, "[]")
+		fieldType = strings.TrimPrefix(fieldType, "[]")
+
+		var fieldSchema *Schema
+		if isSwagPrimitiveType(fieldType) {
+			fieldSchema = swagPrimitiveSchema(fieldType)
+		} else if fieldSchema = p.swagResponseSchema(fieldType); fieldSchema == nil {
+			continue
+		}
+		if isArray {
+			fieldSchema = &Schema{Type: "array", Items: fieldSchema}
+		}
+		schema = schema.withField(field, fieldSchema)
+	}
+	return schema
+}
+
+// swagPrimitiveSchema swag 基础数据类型的 Schema
+func swagPrimitiveSchema(dataType string) *Schema {
+	switch dataType {
+	case "integer", "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return &Schema{Type: "integer"}
+	case "number", "float32", "float64":
+		return &Schema{Type: "number"}
+	case "boolean", "bool":
+		return &Schema{Type: "boolean"}
+	case "object":
+		return &Schema{Type: "object"}
+	case "array":
+		return &Schema{Type: "array", Items: &Schema{}}
+	}
+	return &Schema{Type: "string"}
+}
+
+// swagZeroJson swag 基础类型的零值，作为替换字段的示例值
+func swagZeroJson(dataType string) []byte {
+	switch swagPrimitiveSchema(dataType).Type {
+	case "integer", "number":
+		return []byte("0")
+	case "boolean":
+		return []byte("false")
+	case "object":
+		return []byte("{}")
+	case "array":
+		return []byte("[]")
+	}
+	return []byte(`""`)
+}
+
+// parseSwagResponseType 解析返回的数据类型，支持 swag 的字段替换语法，替换的字段也可以替换字段。
+// 如：comm.Result{data=Detail}、comm.Result{data=[]ListItem}、comm.Result{data=Page{items=[]Item},code=int}
+func (p *ApiDoc) parseSwagResponseType(dataType string) (params []runapi.ResponseParam, paramJson []byte, err error) {
+	baseType, overrides := splitSwagResponseType(dataType)
+	params, paramJson, err = p.parseResponseParam(baseType + "{}")
+	if err != nil || overrides == "" {
+		return
+	}
+
+	for _, override := range splitSwagOverrides(overrides) {
+		kv := strings.SplitN(override, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		field, fieldType := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		isArray := strings.HasPrefix(fieldType, "[]")
+		fieldType = strings.TrimPrefix(fieldType, "[]")
+
+		var fieldParams []runapi.ResponseParam
+		var fieldJson []byte
+		if isSwagPrimitiveType(fieldType) {
+			fieldJson = swagZeroJson(fieldType)
+		} else if fieldParams, fieldJson, err = p.parseSwagResponseType(fieldType); err != nil {
+			return
+		}
+		if isArray {
+			fieldJson = append(append([]byte("["), fieldJson...), ']')
+		}
+
+		// 替换字段的子字段和示例值
+		replaced := make([]runapi.ResponseParam, 0, len(params)+len(fieldParams))
+		for _, param := range params {
+			if !strings.HasPrefix(param.Name, field+".") {
+				replaced = append(replaced, param)
+			}
+		}
+		for _, param := range fieldParams {
+			param.Name = field + "." + param.Name
+			replaced = append(replaced, param)
+		}
+		params = replaced
+		if paramJson, err = sjson.SetRawBytes(paramJson, field, fieldJson); err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,51 @@
+package infer
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @catalog 推断
+type Handler struct{}
+
+type ErrorRsp struct {
+	Code int    `json:"code"` // 错误代码
+	Msg  string `json:"msg"`  // 错误说明
+}
+
+type SaveReq struct {
+	Title string `json:"title" binding:"required"` // 书名
+	Pages int    `json:"pages"`                    // 页数
+}
+
+type SaveRsp struct {
+	ID string `json:"id"` // 书籍 id
+}
+
+type ListQuery struct {
+	Page     int `form:"page" json:"page"`           // 第几页
+	PageSize int `form:"page_size" json:"page_size"` // 每页显示条数
+}
+
+// Save 保存书籍
+//
+// @url POST {{BASEURL}}/books
+func (h *Handler) Save(c *gin.Context) {
+	var req SaveReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorRsp{Code: 1, Msg: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, &SaveRsp{ID: "1"})
+}
+
+// List 书籍列表
+//
+// @url GET {{BASEURL}}/books
+// @resp page int "第几页"
+func (h *Handler) List(c *gin.Context) {
+	q := new(ListQuery)
+	_ = c.ShouldBindQuery(q)
+	c.JSON(http.StatusOK, gin.H{"page": q.Page})
+}
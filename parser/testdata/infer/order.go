@@ -0,0 +1,26 @@
+package infer
+
+// OrderStatus 订单状态
+type OrderStatus int
+
+const (
+	StatusNew  OrderStatus = iota + 1 // 新建
+	StatusPaid                        // 已支付
+	// StatusClosed 已关闭
+	StatusClosed
+)
+
+// PayChannel 支付渠道
+type PayChannel string
+
+const (
+	ChannelAlipay PayChannel = "alipay" // 支付宝
+	ChannelWechat PayChannel = "wechat" // 微信
+)
+
+type Order struct {
+	Status   OrderStatus   `json:"status"`   // 订单状态
+	Channel  PayChannel    `json:"channel"`  // 支付渠道
+	History  []OrderStatus `json:"history"`  // 历史状态
+	Quantity int           `json:"quantity"` // 数量
+}
@@ -0,0 +1,3 @@
+module params
+
+go 1.22
@@ -0,0 +1,38 @@
+package params
+
+type ListReq struct {
+	Page     int    `form:"page" json:"page"`          // 第几页
+	PageSize int    `form:"page_size" json:"pageSize"` // 每页显示条数
+	Keyword  string `json:"keyword"`                   // 没有 form 标签时使用 json 标签
+	Internal string `form:"-" json:"internal"`         // 忽略
+}
+
+type AuthHeader struct {
+	Token   string `header:"Authorization" binding:"required"` // 用户登录凭证
+	TraceID string `header:"X-Trace-Id"`                       // 请求 id
+	UserID  int64  `json:"user_id" header:"-"`                 // 忽略
+}
+
+type BookUri struct {
+	ID      int64  `uri:"id" binding:"required"` // 书籍 id
+	Chapter string `param:"chapter"`             // 章节
+}
+
+// ListBooks 书籍列表
+//
+// @url GET {{BASEURL}}/books
+// @header AuthHeader{}
+// @query ListReq{}
+func ListBooks() {}
+
+// GetChapter 书籍章节
+//
+// @url GET {{BASEURL}}/books/:id/:chapter
+// @path_var BookUri{}
+func GetChapter() {}
+
+// SearchBooks 搜索书籍，@param 的结构体参数使用 json 标签
+//
+// @url GET {{BASEURL}}/books/search
+// @param ListReq{}
+func SearchBooks() {}
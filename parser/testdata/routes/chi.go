@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func NewChiRouter(h *Handler, method string) http.Handler {
+	r := chi.NewRouter()
+	r.Route("/api", func(r chi.Router) {
+		r.Get("/authors/{authorID}", h.GetAuthor)
+		r.Group(func(r chi.Router) {
+			r.Delete("/authors/{authorID:[0-9]+}", h.DeleteAuthor)
+		})
+	})
+	r.Method(http.MethodGet, "/files/*", http.HandlerFunc(h.ListFiles))
+	// 请求方式不是常量，无法确定请求方式
+	r.MethodFunc(method, "/authors", h.ListAuthors)
+	return r
+}
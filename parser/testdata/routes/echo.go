@@ -0,0 +1,19 @@
+package routes
+
+import "github.com/labstack/echo/v4"
+
+func NewEcho(version, method string) *echo.Echo {
+	e := echo.New()
+	g := e.Group("/v2")
+	g.GET("/reviews/:id", GetReview, logger)
+	e.Add("PUT", "/v2/reviews/:id", UpdateReview)
+	// 请求方式不是常量，无法确定请求方式
+	e.Add(method, "/v2/reviews", ListReviews)
+	// 分组路径不是常量，无法确定完整路径
+	e.Group("/"+version).GET("/comments", ListComments)
+	return e
+}
+
+func logger(next echo.HandlerFunc) echo.HandlerFunc {
+	return next
+}
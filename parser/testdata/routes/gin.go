@@ -0,0 +1,14 @@
+package routes
+
+import "github.com/gin-gonic/gin"
+
+func NewGin(method string) *gin.Engine {
+	r := gin.New()
+	r.Handle("PATCH", "/publishers/:id", UpdatePublisher)
+	// 请求方式不是常量，无法确定请求方式
+	r.Handle(method, "/publishers", ListPublishers)
+	return r
+}
+
+func UpdatePublisher(c *gin.Context) {}
+func ListPublishers(c *gin.Context)  {}
@@ -0,0 +1,12 @@
+package routes
+
+import "net/http"
+
+func NewServeMux(h *Handler) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /books", h.ListBooks)
+	mux.HandleFunc("GET /books/{id}", h.GetBook)
+	mux.Handle("POST /books", http.HandlerFunc(h.CreateBook))
+	mux.Handle("/healthz", Health{})
+	return mux
+}
@@ -0,0 +1,3 @@
+module swag
+
+go 1.22
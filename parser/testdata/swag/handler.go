@@ -0,0 +1,58 @@
+package swag
+
+// Result 通用返回结果
+type Result struct {
+	Code int         `json:"code"` // 错误代码
+	Msg  string      `json:"msg"`  // 错误说明
+	Data interface{} `json:"data"` // 返回数据
+}
+
+type Book struct {
+	ID    int64  `json:"id"`    // 书籍 id
+	Title string `json:"title"` // 书名
+}
+
+type SaveReq struct {
+	Title string `json:"title" binding:"required"` // 书名
+}
+
+// GetBook godoc
+//
+//	@Summary		获取书籍详情
+//	@Description	根据 id 获取书籍详情
+//	@Tags			书籍,公开
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int		true	"书籍 id"
+//	@Param			X-Trace-Id	header		string	false	"请求 id"
+//	@Param			lang		query		string	false	"语言"	default(zh)
+//	@Success		200			{object}	Result{data=Book}
+//	@Failure		400			{object}	Result
+//	@Router			/books/{id} [get]
+func GetBook() {}
+
+// SaveBook godoc
+//
+//	@Summary	保存书籍
+//	@Tags		书籍
+//	@Accept		json
+//	@Param		book	body	SaveReq	true	"书籍"
+//	@Success	200		{array}	Book
+//	@Deprecated
+//	@Router		/books [post]
+func SaveBook() {}
+
+// Page 分页列表
+type Page struct {
+	Total int64       `json:"total"` // 总条数
+	Items interface{} `json:"items"` // 列表项
+}
+
+// ListBooks godoc
+//
+//	@Summary	分页获取书籍
+//	@Tags		书籍
+//	@Tags		管理
+//	@Success	200	{object}	Result{data=Page{items=[]Book},code=int}
+//	@Router		/books [get]
+func ListBooks() {}
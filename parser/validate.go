@@ -0,0 +1,197 @@
+package parser
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// validateRule validate 或 binding 标签中的一条校验规则，如：min=1
+type validateRule struct {
+	Name  string
+	Param string
+}
+
+// validateRules 字段的校验规则，使用 go-playground/validator 的语法。
+// 只保留作用于字段本身的规则，dive 之后作用于元素的规则和 | 组合的规则会被忽略。
+type validateRules []validateRule
+
+// parseValidateRules 解析字段标签中 validate 和 binding 的校验规则。
+// 如：`json:"title" binding:"required,min=1,max=20"`
+func parseValidateRules(tag string) validateRules {
+	var rules validateRules
+	structTag := reflect.StructTag(tag)
+	for _, key := range []string{"validate", "binding"} {
+		value, ok := structTag.Lookup(key)
+		if !ok {
+			continue
+		}
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item == "dive" {
+				break
+			}
+			if item == "" || strings.Contains(item, "|") {
+				continue
+			}
+			rule := validateRule{Name: item}
+			if i := strings.Index(item, "="); i >= 0 {
+				rule.Name, rule.Param = item[:i], unescapeValidateParam(item[i+1:])
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// unescapeValidateParam 还原参数中转义的逗号和竖线
+func unescapeValidateParam(param string) string {
+	return strings.NewReplacer("0x2C", ",", "0x7C", "|").Replace(param)
+}
+
+// Required 是否必填，只有 required 规则是必填，required_if 等条件必填的规则不是
+func (rules validateRules) Required() bool {
+	for _, rule := range rules {
+		if rule.Name == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// Remark 校验规则的说明，如：（最小长度 1，最大长度 20）
+//
+// @param dataType 字段类型，字符串、切片和 map 的 min、max、len 规则是长度限制
+func (rules validateRules) Remark(dataType string) string {
+	isLength := dataType == "string" || strings.HasPrefix(dataType, "[]") || strings.HasPrefix(dataType, "map[")
+	var items []string
+	for _, rule := range rules {
+		var item string
+		switch rule.Name {
+		case "min", "gte":
+			if isLength {
+				item = "最小长度 " + rule.Param
+			} else {
+				item = "最小值 " + rule.Param
+			}
+		case "max", "lte":
+			if isLength {
+				item = "最大长度 " + rule.Param
+			} else {
+				item = "最大值 " + rule.Param
+			}
+		case "len":
+			if isLength {
+				item = "长度 " + rule.Param
+			} else {
+				item = "等于 " + rule.Param
+			}
+		case "gt":
+			item = "大于 " + rule.Param
+		case "lt":
+			item = "小于 " + rule.Param
+		case "eq":
+			item = "等于 " + rule.Param
+		case "ne":
+			item = "不等于 " + rule.Param
+		case "oneof":
+			item = "可选值 " + strings.Join(strings.Fields(rule.Param), "、")
+		case "email":
+			item = "邮箱格式"
+		case "url", "http_url":
+			item = "URL 格式"
+		case "uuid", "uuid4":
+			item = "UUID 格式"
+		case "ip", "ipv4", "ipv6":
+			item = "IP 地址格式"
+		case "numeric", "number":
+			item = "数字格式"
+		case "alphanum":
+			item = "只能包含字母和数字"
+		case "datetime":
+			item = "时间格式 " + rule.Param
+		case "required_if", "required_unless", "required_with", "required_with_all", "required_without", "required_without_all":
+			item = "条件必填 " + rule.Name
+			if rule.Param != "" {
+				item += "=" + rule.Param
+			}
+		}
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	return "（" + strings.Join(items, "，") + "）"
+}
+
+// applySchema 将校验规则转换为 Schema 的约束，如：min=1 => minimum: 1
+func (rules validateRules) applySchema(s *Schema) {
+	if s.Ref != "" {
+		return
+	}
+	number := func(param string) *float64 {
+		if v, err := strconv.ParseFloat(param, 64); err == nil {
+			return &v
+		}
+		return nil
+	}
+	length := func(param string) *int {
+		if v, err := strconv.Atoi(param); err == nil {
+			return &v
+		}
+		return nil
+	}
+	for _, rule := range rules {
+		switch rule.Name {
+		case "min", "gte", "max", "lte", "len":
+			isMin := rule.Name != "max" && rule.Name != "lte"
+			isMax := rule.Name != "min" && rule.Name != "gte"
+			switch s.Type {
+			case "string":
+				if isMin {
+					s.MinLength = length(rule.Param)
+				}
+				if isMax {
+					s.MaxLength = length(rule.Param)
+				}
+			case "array":
+				if isMin {
+					s.MinItems = length(rule.Param)
+				}
+				if isMax {
+					s.MaxItems = length(rule.Param)
+				}
+			case "integer", "number":
+				if isMin {
+					s.Minimum = number(rule.Param)
+				}
+				if isMax {
+					s.Maximum = number(rule.Param)
+				}
+			}
+		case "gt":
+			s.ExclusiveMinimum = number(rule.Param)
+		case "lt":
+			s.ExclusiveMaximum = number(rule.Param)
+		case "oneof":
+			s.Enum = nil
+			for _, item := range strings.Fields(rule.Param) {
+				if v := number(item); v != nil && (s.Type == "integer" || s.Type == "number") {
+					s.Enum = append(s.Enum, *v)
+				} else {
+					s.Enum = append(s.Enum, item)
+				}
+			}
+		case "email":
+			s.Format = "email"
+		case "url", "http_url":
+			s.Format = "uri"
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		case "ipv4", "ipv6":
+			s.Format = rule.Name
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseValidateRules(t *testing.T) {
+	cases := []struct {
+		Tag      string
+		DataType string
+		Required bool
+		Remark   string
+	}{
+		{`json:"title" validate:"required"`, "string", true, ""},
+		{`json:"title" binding:"required,min=1,max=20"`, "string", true, "（最小长度 1，最大长度 20）"},
+		{`json:"pages" validate:"gte=1,lte=100"`, "int", false, "（最小值 1，最大值 100）"},
+		{`json:"email" validate:"omitempty,required_without=Phone,email"`, "string", false, "（条件必填 required_without=Phone，邮箱格式）"},
+		{`json:"color" validate:"oneof=red green blue"`, "string", false, "（可选值 red、green、blue）"},
+		{`json:"tags" validate:"len=3,dive,required"`, "[]string", false, "（长度 3）"},
+		{`json:"site" validate:"required|url"`, "string", false, ""},
+		{`json:"required_field"`, "string", false, ""},
+	}
+
+	Convey("测试解析校验规则", t, func() {
+		for _, c := range cases {
+			rules := parseValidateRules(c.Tag)
+			So(rules.Required(), ShouldEqual, c.Required)
+			So(rules.Remark(c.DataType), ShouldEqual, c.Remark)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/whaios/goshowdoc/log"
+	"github.com/whaios/goshowdoc/parser"
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+// Target 更新文档的 ShowDoc 项目
+type Target struct {
+	Name     string         // 项目名称，用于输出日志
+	Client   *runapi.Client // 项目的客户端，每个项目使用自己的认证凭证
+	ItemId   string         // 项目 id，默认项目为空时使用 --item-id 参数
+	Catalogs []string       // 目录前缀，文档的目录和任意一个前缀匹配时更新到该项目
+	Tags     []string       // 标签，文档有任意一个标签时更新到该项目。和 Catalogs 都为空时为默认项目，接收其他项目以外的文档
+}
+
+// IsDefault 是否为默认项目
+func (t *Target) IsDefault() bool {
+	return len(t.Catalogs) == 0 && len(t.Tags) == 0
+}
+
+// Match 文档是否属于该项目，目录前缀按层级匹配，如：后台 匹配 后台 和 后台/用户，不匹配 后台管理
+func (t *Target) Match(doc *parser.ApiDoc) bool {
+	if doc.HasAnyTag(t.Tags) {
+		return true
+	}
+	for _, prefix := range t.Catalogs {
+		if doc.Catalog == prefix || strings.HasPrefix(doc.Catalog, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// options 更新到该项目的参数，只有默认项目使用 --item-id 参数
+func (t *Target) options(opts UpdateOptions) (UpdateOptions, error) {
+	if t.IsDefault() {
+		if t.ItemId != "" {
+			opts.ItemId = t.ItemId
+		}
+		return opts, nil
+	}
+	opts.ItemId = t.ItemId
+	if opts.ItemId == "" && (opts.Diff || opts.Prune || t.Client.SSID != "") {
+		return opts, fmt.Errorf("项目没有设置 item_id，比较差异、清理文档和设置 --ssid 时需要项目 id")
+	}
+	return opts, nil
+}
+
+// targetDocs 项目和需要更新到该项目的文档
+type targetDocs struct {
+	Target *Target
+	Docs   []*parser.ApiDoc
+}
+
+// splitDocs 将文档分到不同的项目，文档更新到第一个匹配的项目，没有匹配的文档更新到默认项目。
+// 没有文档的项目不返回
+//
+// @param targets 最后一个为默认项目
+func splitDocs(docs []*parser.ApiDoc, targets []*Target) []*targetDocs {
+	groups := make([]*targetDocs, len(targets))
+	for i, target := range targets {
+		groups[i] = &targetDocs{Target: target}
+	}
+	for _, doc := range docs {
+		for _, g := range groups {
+			if g.Target.IsDefault() || g.Target.Match(doc) {
+				g.Docs = append(g.Docs, doc)
+				break
+			}
+		}
+	}
+
+	result := make([]*targetDocs, 0, len(groups))
+	for _, g := range groups {
+		if len(g.Docs) > 0 {
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// publishTargets 将文档分别更新到对应的项目，一个项目更新失败时继续更新其他项目
+func publishTargets(ctx context.Context, targets []*Target, docs []*parser.ApiDoc, opts UpdateOptions) error {
+	if len(targets) == 1 || len(docs) == 0 {
+		return publish(ctx, targets[len(targets)-1].Client, docs, opts)
+	}
+
+	groups := splitDocs(docs, targets)
+	var failed []string
+	for _, g := range groups {
+		name := g.Target.Name
+		if name == "" {
+			name = "默认"
+		}
+		log.Info("更新项目[%s]，共 %d 个文档", name, len(g.Docs))
+		targetOpts, err := g.Target.options(opts)
+		if err == nil {
+			err = publish(ctx, g.Target.Client, g.Docs, targetOpts)
+		}
+		if err != nil {
+			log.Error("更新项目[%s]失败: %s", name, err.Error())
+			failed = append(failed, name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d 个项目更新失败: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/whaios/goshowdoc/parser"
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+func newTaggedDoc(catalog, title string, tags ...string) *parser.ApiDoc {
+	doc := newTestDoc(catalog, title, "/"+title)
+	doc.Tags = tags
+	return doc
+}
+
+func TestTarget_Match(t *testing.T) {
+	Convey("测试文档是否属于项目", t, func() {
+		target := &Target{Catalogs: []string{"后台管理"}, Tags: []string{"admin"}}
+		tests := []struct {
+			doc   *parser.ApiDoc
+			match bool
+		}{
+			{newTaggedDoc("后台管理", "a"), true},
+			{newTaggedDoc("后台管理/用户", "b"), true},
+			{newTaggedDoc("后台管理员", "c"), false},
+			{newTaggedDoc("书籍", "d"), false},
+			{newTaggedDoc("书籍", "e", "public", "admin"), true},
+			{newTaggedDoc("书籍", "f", "public"), false},
+		}
+		for _, tt := range tests {
+			So(target.Match(tt.doc), ShouldEqual, tt.match)
+		}
+		So(target.IsDefault(), ShouldBeFalse)
+		So((&Target{}).IsDefault(), ShouldBeTrue)
+	})
+}
+
+func TestSplitDocs(t *testing.T) {
+	Convey("测试将文档分到不同的项目", t, func() {
+		admin := &Target{Name: "后台", Catalogs: []string{"后台管理"}}
+		public := &Target{Name: "公开", Tags: []string{"public"}}
+		all := &Target{Name: "全部", Catalogs: []string{"后台管理", "书籍"}}
+		def := &Target{}
+		targets := []*Target{admin, public, all, def}
+
+		tests := []struct {
+			doc    *parser.ApiDoc
+			target *Target
+		}{
+			{newTaggedDoc("后台管理/用户", "a"), admin},
+			{newTaggedDoc("后台管理", "b", "public"), admin}, // 更新到第一个匹配的项目
+			{newTaggedDoc("书籍", "c", "public"), public},
+			{newTaggedDoc("书籍", "d"), all},
+			{newTaggedDoc("后台管理员", "e"), def},
+			{newTaggedDoc("", "f"), def},
+		}
+		var docs []*parser.ApiDoc
+		for _, tt := range tests {
+			docs = append(docs, tt.doc)
+		}
+
+		groups := splitDocs(docs, targets)
+		So(len(groups), ShouldEqual, 4)
+		for _, tt := range tests {
+			var found *Target
+			for _, g := range groups {
+				for _, doc := range g.Docs {
+					if doc == tt.doc {
+						found = g.Target
+					}
+				}
+			}
+			So(found, ShouldEqual, tt.target)
+		}
+
+		// 没有文档的项目不返回
+		groups = splitDocs(docs[4:], targets)
+		So(len(groups), ShouldEqual, 1)
+		So(groups[0].Target, ShouldEqual, def)
+	})
+}
+
+func TestPublishTargets(t *testing.T) {
+	ctx := context.Background()
+	docs := []*parser.ApiDoc{
+		newTaggedDoc("后台管理/用户", "a"),
+		newTaggedDoc("书籍", "b", "admin"),
+		newTaggedDoc("书籍", "c"),
+	}
+
+	Convey("测试将文档更新到对应的项目", t, func() {
+		adminFake, defFake := newFakeShowDoc(&runapi.Item{ItemId: "2"}), newFakeShowDoc(&runapi.Item{ItemId: "1"})
+		admin := &Target{Name: "后台", Client: newTestClient(adminFake), ItemId: "2", Catalogs: []string{"后台管理"}, Tags: []string{"admin"}}
+		def := &Target{Client: newTestClient(defFake)}
+
+		So(publishTargets(ctx, []*Target{admin, def}, docs, UpdateOptions{ItemId: "1"}), ShouldBeNil)
+		So(adminFake.count("/api/item/updateByApi"), ShouldEqual, 2)
+		So(defFake.count("/api/item/updateByApi"), ShouldEqual, 1)
+		So(adminFake.count("/api/item/info"), ShouldEqual, 1)
+		So(defFake.count("/api/item/info"), ShouldEqual, 1)
+	})
+
+	Convey("测试项目没有设置 item_id 时不使用 --item-id 参数", t, func() {
+		tests := []struct {
+			ssid string
+			opts UpdateOptions
+			fail bool
+		}{
+			{"", UpdateOptions{ItemId: "1"}, false},
+			{"ssid", UpdateOptions{ItemId: "1"}, true},
+			{"", UpdateOptions{ItemId: "1", Diff: true}, true},
+			{"", UpdateOptions{ItemId: "1", Prune: true, Yes: true}, true},
+		}
+		for _, tt := range tests {
+			adminFake, defFake := newFakeShowDoc(nil), newFakeShowDoc(&runapi.Item{ItemId: "1"})
+			admin := &Target{Name: "后台", Client: newTestClient(adminFake), Catalogs: []string{"后台管理"}}
+			def := &Target{Client: newTestClient(defFake)}
+			admin.Client.SSID = tt.ssid
+
+			err := publishTargets(ctx, []*Target{admin, def}, docs, tt.opts)
+			if tt.fail {
+				So(err, ShouldNotBeNil)
+				So(adminFake.count("/api/item/updateByApi"), ShouldEqual, 0)
+			} else {
+				So(err, ShouldBeNil)
+				So(adminFake.count("/api/item/updateByApi"), ShouldEqual, 1)
+			}
+			// 其他项目更新失败时继续更新默认项目
+			So(adminFake.count("/api/item/info"), ShouldEqual, 0)
+			So(defFake.count("/api/item/updateByApi"), ShouldBeGreaterThan, 0)
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/whaios/goshowdoc/log"
+	"github.com/whaios/goshowdoc/parser"
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+// 清理文档的方式
+const (
+	PruneArchive = "archive" // 移动到归档目录
+	PruneDelete  = "delete"  // 删除
+)
+
+// DefaultArchiveCatalog 默认的归档目录
+const DefaultArchiveCatalog = "已归档"
+
+// prunePage 需要清理的文档
+type prunePage struct {
+	Name   string // 目录和标题，如：一层/二层/标题
+	PageId string
+}
+
+// checkPruneTags 按标签过滤文档时不能清理文档，否则过滤掉的文档会被当作删除的接口清理
+func checkPruneTags(opts UpdateOptions, includeTags, excludeTags []string) error {
+	if opts.Prune && (len(includeTags) > 0 || len(excludeTags) > 0) {
+		return errors.New("--prune 不能和 --include-tags、--exclude-tags 参数（包括配置文件中的 include_tags、exclude_tags）一起使用")
+	}
+	return nil
+}
+
+// prune 清理 ShowDoc 中本次没有生成的文档。
+// 清理的范围为本次生成的文档所在的目录，以及状态文件中记录的之前上传过的文档，不会清理其他目录中手动编写的文档。
+func (p *publisher) prune(docs []*parser.ApiDoc) error {
+	opts, state := p.opts, p.state
+	if len(docs) == 0 {
+		log.Warn("没有生成文档，不清理 ShowDoc 中的文档")
+		return nil
+	}
+	switch opts.PruneMode {
+	case PruneArchive, PruneDelete:
+	default:
+		return fmt.Errorf("不支持的清理方式: %s", opts.PruneMode)
+	}
+	if opts.ItemId == "" || p.client.SSID == "" {
+		return errors.New("清理文档需要设置 --item-id 和 --ssid 参数")
+	}
+	item, err := p.client.ItemInfo(p.ctx, opts.ItemId)
+	if err != nil {
+		return fmt.Errorf("查询项目[%s]失败: %s", opts.ItemId, err.Error())
+	}
+
+	pages := prunePages(docs, item.PageIds(), state, opts.ArchiveCatalog)
+	if len(pages) == 0 {
+		log.Info("没有需要清理的文档")
+		return nil
+	}
+	for i, page := range pages {
+		log.Warn("清理文档(%d) %s", i+1, page.Name)
+	}
+	prompt := fmt.Sprintf("确认删除以上 %d 个文档？", len(pages))
+	if opts.PruneMode == PruneArchive {
+		prompt = fmt.Sprintf("确认将以上 %d 个文档移动到目录[%s]？", len(pages), opts.ArchiveCatalog)
+	}
+	if opts.DryRun {
+		log.Info("共 %d 个文档需要清理", len(pages))
+		return nil
+	}
+	if !opts.Yes && !confirm(prompt) {
+		log.Info("取消清理文档")
+		return nil
+	}
+
+	var archiveCatId string
+	if opts.PruneMode == PruneArchive {
+		if archiveCatId, err = p.ensureCatalog(item, opts.ArchiveCatalog); err != nil {
+			return fmt.Errorf("创建目录[%s]失败: %s", opts.ArchiveCatalog, err.Error())
+		}
+	}
+	for i, page := range pages {
+		if opts.PruneMode == PruneDelete {
+			err = p.client.PageDelete(p.ctx, page.PageId)
+		} else {
+			err = p.movePage(page.PageId, archiveCatId)
+		}
+		if err != nil {
+			_ = state.Save()
+			return fmt.Errorf("清理文档[%s]失败: %s", page.Name, err.Error())
+		}
+		state.Delete(page.Name)
+		log.DrawProgressBar("清理文档", i+1, len(pages))
+	}
+	if err := state.Save(); err != nil {
+		return fmt.Errorf("保存状态文件失败: %s", err.Error())
+	}
+	log.Success("清理完成，共 %d 个文档", len(pages))
+	return nil
+}
+
+// prunePages 项目中需要清理的文档：在本次生成的文档所在的目录中，或者在状态文件中有记录，并且不是本次生成的文档。
+// 根目录中的文档只在状态文件中有记录时清理
+//
+// @param pageIds 项目中所有的文档，key 为目录和标题
+// @param archiveCatalog 归档目录，其中的文档不再清理
+func prunePages(docs []*parser.ApiDoc, pageIds map[string]string, state *State, archiveCatalog string) []*prunePage {
+	names := make(map[string]bool)
+	catalogs := make(map[string]bool)
+	for _, doc := range docs {
+		names[doc.Name()] = true
+		if doc.Catalog != "" {
+			catalogs[doc.Catalog] = true
+		}
+	}
+
+	pages := make([]*prunePage, 0)
+	for name, pageId := range pageIds {
+		if names[name] {
+			continue
+		}
+		catalog := ""
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			catalog = name[:i]
+		}
+		if catalog == archiveCatalog || strings.HasPrefix(catalog, archiveCatalog+"/") {
+			continue
+		}
+		if _, ok := state.Hash(name); ok || catalogs[catalog] {
+			pages = append(pages, &prunePage{Name: name, PageId: pageId})
+		}
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].Name < pages[j].Name
+	})
+	return pages
+}
+
+// ensureCatalog 查找目录的 cat_id，目录不存在时逐层创建，并添加到项目的目录中
+//
+// @param catName 多层目录用斜杆隔开，为空时返回根目录 "0"
+func (p *publisher) ensureCatalog(item *runapi.Item, catName string) (string, error) {
+	if catName == "" {
+		return "0", nil
+	}
+	catalogs := &item.Menu.Catalogs
+	var parentCatId string
+	for _, name := range strings.Split(catName, "/") {
+		var found *runapi.Catalog
+		for _, cat := range *catalogs {
+			if cat.CatName == name {
+				found = cat
+				break
+			}
+		}
+		if found == nil {
+			catId, err := p.client.CatalogSave(p.ctx, item.ItemId, parentCatId, name)
+			if err != nil {
+				return "", err
+			}
+			found = &runapi.Catalog{ItemId: item.ItemId, CatId: catId, CatName: name, ParentCatId: parentCatId}
+			*catalogs = append(*catalogs, found)
+		}
+		parentCatId, catalogs = found.CatId, &found.Catalogs
+	}
+	return parentCatId, nil
+}
+
+// movePage 将文档移动到指定目录
+func (p *publisher) movePage(pageId, catId string) error {
+	page, err := p.client.PageInfo(p.ctx, pageId)
+	if err != nil {
+		return err
+	}
+	page.CatId = catId
+	return p.client.PageSave(p.ctx, page)
+}
+
+// confirm 在命令行中确认操作，输入 y 或 yes 时返回 true
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N] ", prompt)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
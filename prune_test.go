@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/whaios/goshowdoc/parser"
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+func TestPrunePages(t *testing.T) {
+	client := runapi.NewClient("http://showdoc.test", "key", "token")
+	docs := []*parser.ApiDoc{
+		newTestDoc("书籍", "列表", "/books"),
+		newTestDoc("书籍/管理", "删除", "/books/:id"),
+		newTestDoc("", "登录", "/login"), // 根目录中的文档
+	}
+	pageIds := map[string]string{
+		"书籍/列表":       "1",
+		"书籍/详情":       "2", // 本次生成的文档所在的目录
+		"书籍/管理/删除":    "3",
+		"作者/列表":       "4", // 状态文件中有记录
+		"手册/安装":       "5", // 其他目录中手动编写的文档
+		"已归档/书籍/详情":   "6", // 归档目录
+		"已归档/作者/详情":   "7",
+		"首页":          "8", // 根目录中手动编写的文档
+		"登录":          "10",
+		"注销":          "11", // 根目录中状态文件有记录的文档
+		"书籍/管理/批量/删除": "9",  // 子目录不在本次生成的文档所在的目录中
+	}
+
+	Convey("测试选择需要清理的文档", t, func() {
+		state, _ := LoadState("", client)
+		state.SetHash("作者/列表", "hash")
+		state.SetHash("已归档/作者/详情", "hash")
+		state.SetHash("注销", "hash")
+
+		pages := prunePages(docs, pageIds, state, DefaultArchiveCatalog)
+		So(pages, ShouldResemble, []*prunePage{
+			{Name: "书籍/详情", PageId: "2"},
+			{Name: "作者/列表", PageId: "4"},
+			{Name: "注销", PageId: "11"},
+		})
+	})
+
+	Convey("测试没有状态记录时只清理本次生成的文档所在的目录", t, func() {
+		state, _ := LoadState("", client)
+		pages := prunePages(docs, pageIds, state, "书籍/管理")
+		So(pages, ShouldResemble, []*prunePage{
+			{Name: "书籍/详情", PageId: "2"},
+		})
+	})
+}
+
+func TestPublisher_Prune(t *testing.T) {
+	Convey("测试没有生成文档时不清理", t, func() {
+		fake := newFakeShowDoc(&runapi.Item{ItemId: "1"})
+		p := newTestPublisher(fake, UpdateOptions{ItemId: "1", PruneMode: PruneDelete, Yes: true})
+		p.state.SetHash("书籍/列表", "hash1")
+		So(p.prune(nil), ShouldBeNil)
+		So(fake.count("/api/item/info"), ShouldEqual, 0)
+	})
+}
+
+func TestPublisher_EnsureCatalog(t *testing.T) {
+	Convey("测试逐层创建目录，已经创建的目录不重复创建", t, func() {
+		item := &runapi.Item{ItemId: "1"}
+		item.Menu.Catalogs = []*runapi.Catalog{{CatId: "10", CatName: "已归档"}}
+		fake := newFakeShowDoc(item)
+		p := newTestPublisher(fake, UpdateOptions{})
+
+		catId, err := p.ensureCatalog(item, "已归档/2024/书籍")
+		So(err, ShouldBeNil)
+		So(catId, ShouldEqual, "1002")
+		So(fake.count("/api/catalog/save"), ShouldEqual, 2)
+
+		for i := 0; i < 3; i++ {
+			catId, err = p.ensureCatalog(item, "已归档/2024/书籍")
+			So(err, ShouldBeNil)
+			So(catId, ShouldEqual, "1002")
+		}
+		So(fake.count("/api/catalog/save"), ShouldEqual, 2)
+
+		catId, err = p.ensureCatalog(item, "")
+		So(err, ShouldBeNil)
+		So(catId, ShouldEqual, "0")
+	})
+}
@@ -1,61 +1,58 @@
 package runapi
 
 import (
-	"fmt"
-
-	"github.com/levigross/grequests"
+	"context"
 )
 
-var SSID = "" // ShowDoc用户的SessionId，浏览器登录后从Cookie中获取PHPSESSID。
-
 // PageSave 保存接口文档。
-func PageSave(page *Page) error {
-	url := fmt.Sprintf("%s/server/?s=/api/page/save", Host)
+func (c *Client) PageSave(ctx context.Context, page *Page) error {
+	result := ErrResult{}
+	if err := c.post(ctx, "/api/page/save", page.ToMap(), &result); err != nil {
+		return err
+	}
+	return result.Error()
+}
 
+// PageDelete 删除接口文档。
+func (c *Client) PageDelete(ctx context.Context, pageId string) error {
 	result := ErrResult{}
-	if err := post(url, page.ToMap(), &result); err != nil {
+	if err := c.post(ctx, "/api/page/delete", map[string]string{"page_id": pageId}, &result); err != nil {
 		return err
 	}
 	return result.Error()
 }
 
 // PageInfo 接口文档详情。
-func PageInfo(pageId string) (*Page, error) {
-	url := fmt.Sprintf("%s/server/?s=/api/page/info", Host)
-
+func (c *Client) PageInfo(ctx context.Context, pageId string) (*Page, error) {
 	result := struct {
 		ErrResult
 		Data *Page `json:"data"`
 	}{}
-	if err := post(url, map[string]string{"page_id": pageId}, &result); err != nil {
+	if err := c.post(ctx, "/api/page/info", map[string]string{"page_id": pageId}, &result); err != nil {
 		return nil, err
 	}
 	return result.Data, result.Error()
 }
 
 // ItemInfo 项目目录列表。
-func ItemInfo(itemId string) (*Item, error) {
-	url := fmt.Sprintf("%s/server/?s=/api/item/info", Host)
-
+func (c *Client) ItemInfo(ctx context.Context, itemId string) (*Item, error) {
 	result := struct {
 		ErrResult
 		Data *Item `json:"data"`
 	}{}
-	if err := post(url, map[string]string{"item_id": itemId}, &result); err != nil {
+	if err := c.post(ctx, "/api/item/info", map[string]string{"item_id": itemId}, &result); err != nil {
 		return nil, err
 	}
 	return result.Data, result.Error()
 }
 
 // CatalogSave 保存目录。
-func CatalogSave(itemId, parentCatId, catName string) (string, error) {
-	url := fmt.Sprintf("%s/server/?s=/api/catalog/save", Host)
-
+func (c *Client) CatalogSave(ctx context.Context, itemId, parentCatId, catName string) (string, error) {
 	result := struct {
 		ErrResult
 		Id string `json:"data"`
 	}{}
-	if err := post(url, map[string]string{
+	if err := c.post(ctx, "/api/catalog/save", map[string]string{
 		"item_id":       itemId,
 		"parent_cat_id": parentCatId,
 		"cat_name":      catName,
@@ -64,16 +61,3 @@ func CatalogSave(itemId, parentCatId, catName string) (string, error) {
 	}
 	return result.Id, result.Error()
 }
-
-func post(url string, data map[string]string, result interface{}) error {
-	resp, err := grequests.Post(url, &grequests.RequestOptions{
-		Data: data,
-		Headers: map[string]string{
-			"Cookie": "PHPSESSID=" + SSID,
-		},
-	})
-	if err != nil {
-		return err
-	}
-	return resp.JSON(result)
-}
@@ -65,6 +65,26 @@ func collPages(catalogs []*Catalog) []*MenuPage {
 	return pages
 }
 
+// PageIds 项目下所有接口文档的 page_id，key 为目录和标题，如：一层/二层/标题，没有目录的文档为标题
+func (p *Item) PageIds() map[string]string {
+	ids := make(map[string]string)
+	for _, page := range p.Menu.Pages {
+		ids[page.PageTitle] = page.PageId
+	}
+	collPageIds(p.Menu.Catalogs, "", ids)
+	return ids
+}
+
+func collPageIds(catalogs []*Catalog, parent string, ids map[string]string) {
+	for _, cat := range catalogs {
+		catName := parent + cat.CatName
+		for _, page := range cat.Pages {
+			ids[catName+"/"+page.PageTitle] = page.PageId
+		}
+		collPageIds(cat.Catalogs, catName+"/", ids)
+	}
+}
+
 // Catalog 目录
 type Catalog struct {
 	ItemId  string `json:"item_id"`
@@ -91,6 +111,7 @@ type Page struct {
 	PageId      string `json:"page_id"`
 	PageTitle   string `json:"page_title"`
 	PageContent string `json:"page_content"`
+	SNumber     string `json:"s_number,omitempty"` // 页面序号，数字越小越靠前
 }
 
 // HtmlUnescape HTML解码
@@ -29,3 +29,21 @@ func TestPageContent_SetQuery(t *testing.T) {
 		}
 	})
 }
+
+func TestItem_PageIds(t *testing.T) {
+	Convey("测试项目下接口文档的 page_id", t, func() {
+		item := &Item{}
+		item.Menu.Pages = []*MenuPage{{PageId: "1", PageTitle: "首页"}}
+		item.Menu.Catalogs = []*Catalog{{
+			CatName: "测试文档",
+			Catalogs: []*Catalog{{
+				CatName: "书籍",
+				Pages:   []*MenuPage{{PageId: "2", PageTitle: "获取书籍列表"}},
+			}},
+		}}
+		So(item.PageIds(), ShouldResemble, map[string]string{
+			"首页":             "1",
+			"测试文档/书籍/获取书籍列表": "2",
+		})
+	})
+}
@@ -0,0 +1,179 @@
+package runapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whaios/goshowdoc/log"
+)
+
+// DefaultHost ShowDoc 官方线上地址
+const DefaultHost = "https://www.showdoc.com.cn"
+
+// 请求的默认参数
+const (
+	DefaultTimeout   = 30 * time.Second       // 单次请求的超时时间
+	DefaultRetries   = 3                      // 失败后的重试次数
+	DefaultRetryWait = 500 * time.Millisecond // 第一次重试前的等待时间，之后每次翻倍
+	DefaultRateLimit = 5                      // 官方线上地址每秒最多发送的请求数
+)
+
+// Client ShowDoc 接口的客户端，可以在多个 goroutine 中同时使用。
+// 开放 API（UpdateByApi）使用 api_key 和 api_token 认证，其他接口使用登录用户的 SessionId 认证。
+type Client struct {
+	Host       string       // ShowDoc 地址，默认 "https://www.showdoc.com.cn"
+	ApiKey     string       // 认证凭证。登录showdoc，进入具体项目后，点击右上角的”项目设置”-“开放API”便可看到
+	ApiToken   string       // 认证凭证
+	SSID       string       // ShowDoc用户的SessionId，浏览器登录后从Cookie中获取PHPSESSID。
+	HttpClient *http.Client // 发送请求的客户端，默认为 http.DefaultClient
+
+	Timeout   time.Duration // 单次请求的超时时间，0 表示不限制
+	Retries   int           // 网络错误、服务端 5xx 错误或返回 HTML 页面时的重试次数
+	RetryWait time.Duration // 第一次重试前的等待时间，之后每次翻倍
+	RateLimit float64       // 每秒最多发送的请求数，0 表示不限制
+
+	mu   sync.Mutex
+	next time.Time // 限流时下一个请求最早的发送时间
+}
+
+// NewClient 创建 ShowDoc 客户端，使用官方线上地址时默认限制每秒 DefaultRateLimit 个请求
+//
+// @param host 为空时使用 DefaultHost
+func NewClient(host, apiKey, apiToken string) *Client {
+	if host == "" {
+		host = DefaultHost
+	}
+	c := &Client{
+		Host:       host,
+		ApiKey:     apiKey,
+		ApiToken:   apiToken,
+		HttpClient: http.DefaultClient,
+		Timeout:    DefaultTimeout,
+		Retries:    DefaultRetries,
+		RetryWait:  DefaultRetryWait,
+	}
+	if strings.TrimRight(host, "/") == DefaultHost {
+		c.RateLimit = DefaultRateLimit
+	}
+	return c
+}
+
+// retryableError 可以重试的错误：网络错误、服务端 5xx 错误或返回了 HTML 页面（如网关错误页、登录页）
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+
+func (e *retryableError) Unwrap() error { return e.err }
+
+// post 以表单格式发送请求，并解析返回的 JSON。可以重试的错误按指数退避重试 Retries 次
+//
+// @param api 接口名称，如：/api/page/save
+func (c *Client) post(ctx context.Context, api string, data map[string]string, result interface{}) error {
+	form := url.Values{}
+	for key, value := range data {
+		form.Set(key, value)
+	}
+	body := form.Encode()
+
+	wait := c.RetryWait
+	for i := 0; ; i++ {
+		err := c.do(ctx, api, body, result)
+		var retryErr *retryableError
+		if err == nil || !errors.As(err, &retryErr) || i >= c.Retries || ctx.Err() != nil {
+			return err
+		}
+		log.Debug("请求 %s 失败，%s 后重试(%d/%d): %s", api, wait, i+1, c.Retries, err.Error())
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		wait *= 2
+	}
+}
+
+// do 发送一次请求
+func (c *Client) do(ctx context.Context, api, body string, result interface{}) error {
+	if err := c.waitRateLimit(ctx); err != nil {
+		return err
+	}
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	reqUrl := fmt.Sprintf("%s/server/?s=%s", strings.TrimRight(c.Host, "/"), api)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqUrl, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if c.SSID != "" {
+		req.AddCookie(&http.Cookie{Name: "PHPSESSID", Value: c.SSID})
+	}
+
+	httpClient := c.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return &retryableError{err}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &retryableError{err}
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return &retryableError{fmt.Errorf("服务端错误: %s", resp.Status)}
+	}
+	if isHtml(data) {
+		return &retryableError{fmt.Errorf("返回了 HTML 页面: %s", resp.Status)}
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("请求失败: %s", resp.Status)
+	}
+	return json.Unmarshal(data, result)
+}
+
+// waitRateLimit 限流，等待到可以发送下一个请求
+func (c *Client) waitRateLimit(ctx context.Context) error {
+	if c.RateLimit <= 0 {
+		return nil
+	}
+	interval := time.Duration(float64(time.Second) / c.RateLimit)
+	c.mu.Lock()
+	now := time.Now()
+	at := c.next
+	if at.Before(now) {
+		at = now
+	}
+	c.next = at.Add(interval)
+	c.mu.Unlock()
+
+	if d := at.Sub(now); d > 0 {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// isHtml 返回的内容是否为 HTML 页面。ShowDoc 返回 JSON 时 Content-Type 也可能是 text/html，所以只检查内容
+func isHtml(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("<"))
+}
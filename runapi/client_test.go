@@ -0,0 +1,103 @@
+package runapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestClient(t *testing.T) {
+	var req *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		req = r
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := NewClient(server.URL, "key", "token")
+
+	Convey("测试开放 API 更新文档", t, func() {
+		body = `{"error_code":0,"data":{"page_id":123}}`
+		pageId, err := client.UpdateByApi(ctx, "书籍", "列表", "1", "{}")
+		So(err, ShouldBeNil)
+		So(pageId, ShouldEqual, "123")
+		So(req.URL.Query().Get("s"), ShouldEqual, "/api/item/updateByApi")
+		So(req.PostForm.Get("api_key"), ShouldEqual, "key")
+		So(req.PostForm.Get("api_token"), ShouldEqual, "token")
+		So(req.PostForm.Get("cat_name"), ShouldEqual, "书籍")
+		So(req.PostForm.Get("page_title"), ShouldEqual, "列表")
+	})
+
+	Convey("测试使用 SessionId 查询项目", t, func() {
+		client.SSID = "ssid"
+		defer func() { client.SSID = "" }()
+		body = `{"error_code":0,"data":{"item_id":"1","menu":{"pages":[{"page_id":"2","page_title":"首页"}]}}}`
+		item, err := client.ItemInfo(ctx, "1")
+		So(err, ShouldBeNil)
+		So(item.PageIds(), ShouldResemble, map[string]string{"首页": "2"})
+		cookie, err := req.Cookie("PHPSESSID")
+		So(err, ShouldBeNil)
+		So(cookie.Value, ShouldEqual, "ssid")
+	})
+
+	Convey("测试返回错误码", t, func() {
+		body = `{"error_code":10101,"error_message":"没有权限"}`
+		err := client.PageDelete(ctx, "2")
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "没有权限")
+	})
+}
+
+func TestClient_Retry(t *testing.T) {
+	var count int
+	var responses []func(w http.ResponseWriter)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		responses[count](w)
+		count++
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := NewClient(server.URL, "key", "token")
+	client.RetryWait = time.Millisecond
+	badGateway := func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) }
+	html := func(w http.ResponseWriter) { w.Write([]byte("<html><body>502 Bad Gateway</body></html>")) }
+	ok := func(w http.ResponseWriter) { w.Write([]byte(`{"error_code":0}`)) }
+
+	Convey("测试服务端错误和 HTML 页面时重试", t, func() {
+		count, responses = 0, []func(w http.ResponseWriter){badGateway, html, ok}
+		So(client.PageDelete(ctx, "1"), ShouldBeNil)
+		So(count, ShouldEqual, 3)
+	})
+
+	Convey("测试超过重试次数", t, func() {
+		count, responses = 0, []func(w http.ResponseWriter){badGateway, badGateway, badGateway, badGateway}
+		So(client.PageDelete(ctx, "1"), ShouldNotBeNil)
+		So(count, ShouldEqual, client.Retries+1)
+	})
+
+	Convey("测试客户端错误不重试", t, func() {
+		notFound := func(w http.ResponseWriter) { w.WriteHeader(http.StatusNotFound) }
+		count, responses = 0, []func(w http.ResponseWriter){notFound, ok}
+		So(client.PageDelete(ctx, "1"), ShouldNotBeNil)
+		So(count, ShouldEqual, 1)
+	})
+
+	Convey("测试限流", t, func() {
+		client.RateLimit = 50
+		defer func() { client.RateLimit = 0 }()
+		count, responses = 0, []func(w http.ResponseWriter){ok, ok, ok}
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			So(client.PageDelete(ctx, "1"), ShouldBeNil)
+		}
+		So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 40*time.Millisecond)
+	})
+}
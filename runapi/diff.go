@@ -0,0 +1,112 @@
+package runapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 字段差异的类型
+const (
+	DiffAdded    = "+" // 新增的字段
+	DiffRemoved  = "-" // 删除的字段
+	DiffModified = "~" // 修改的字段
+)
+
+// FieldDiff 两个 JSON 文档中一个字段的差异
+type FieldDiff struct {
+	Op   string // 差异的类型，如：DiffModified
+	Path string // 字段的路径，如：request.headers[0].value
+	Old  string // 原来的值，JSON 格式，新增的字段为空
+	New  string // 新的值，JSON 格式，删除的字段为空
+}
+
+// String 差异的文本格式，如：~ info.title: "旧标题" => "新标题"
+func (d *FieldDiff) String() string {
+	switch d.Op {
+	case DiffAdded:
+		return d.Op + " " + d.Path + ": " + d.New
+	case DiffRemoved:
+		return d.Op + " " + d.Path + ": " + d.Old
+	default:
+		return d.Op + " " + d.Path + ": " + d.Old + " => " + d.New
+	}
+}
+
+// DiffJson 比较两个 JSON 文档，返回字段级别的差异。
+// 对象和数组展开为叶子字段后逐个比较，差异按字段在文档中的顺序排列。
+func DiffJson(oldJson, newJson string) ([]*FieldDiff, error) {
+	var oldValue, newValue interface{}
+	if err := json.Unmarshal([]byte(oldJson), &oldValue); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(newJson), &newValue); err != nil {
+		return nil, err
+	}
+	oldPaths, oldFields := flattenJson(oldValue)
+	newPaths, newFields := flattenJson(newValue)
+
+	diffs := make([]*FieldDiff, 0)
+	for _, path := range newPaths {
+		newField := newFields[path]
+		oldField, ok := oldFields[path]
+		if !ok {
+			diffs = append(diffs, &FieldDiff{Op: DiffAdded, Path: path, New: newField})
+		} else if oldField != newField {
+			diffs = append(diffs, &FieldDiff{Op: DiffModified, Path: path, Old: oldField, New: newField})
+		}
+	}
+	for _, path := range oldPaths {
+		if _, ok := newFields[path]; !ok {
+			diffs = append(diffs, &FieldDiff{Op: DiffRemoved, Path: path, Old: oldFields[path]})
+		}
+	}
+	return diffs, nil
+}
+
+// flattenJson 将 JSON 值展开为叶子字段，空的对象和数组也作为叶子字段
+//
+// @return paths 字段路径，按字段在文档中的顺序排列，对象的字段按名称排序
+// @return fields 字段路径对应的值，JSON 格式
+func flattenJson(value interface{}) (paths []string, fields map[string]string) {
+	fields = make(map[string]string)
+	var walk func(path string, value interface{})
+	walk = func(path string, value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if len(v) > 0 {
+				keys := make([]string, 0, len(v))
+				for key := range v {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				for _, key := range keys {
+					if path == "" {
+						walk(key, v[key])
+					} else {
+						walk(path+"."+key, v[key])
+					}
+				}
+				return
+			}
+		case []interface{}:
+			if len(v) > 0 {
+				for i, item := range v {
+					walk(path+"["+strconv.Itoa(i)+"]", item)
+				}
+				return
+			}
+		}
+		// 不转义 & < > 等字符，和文档中的原文一致
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		_ = encoder.Encode(value)
+		paths = append(paths, path)
+		fields[path] = strings.TrimSuffix(buf.String(), "\n")
+	}
+	walk("", value)
+	return paths, fields
+}
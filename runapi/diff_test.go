@@ -0,0 +1,36 @@
+package runapi
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDiffJson(t *testing.T) {
+	Convey("测试比较 JSON 文档", t, func() {
+		oldJson := `{"info":{"title":"旧标题","url":"/book?a=1"},"request":{"headers":[{"name":"Authorization"},{"name":"Accept"}],"query":[]}}`
+		newJson := `{"info":{"title":"新标题","url":"/book?a=1&b=2","remark":"备注"},"request":{"headers":[{"name":"Authorization"}],"query":[{"name":"page"}]}}`
+		diffs, err := DiffJson(oldJson, newJson)
+		So(err, ShouldBeNil)
+
+		var lines []string
+		for _, diff := range diffs {
+			lines = append(lines, diff.String())
+		}
+		So(lines, ShouldResemble, []string{
+			`+ info.remark: "备注"`,
+			`~ info.title: "旧标题" => "新标题"`,
+			`~ info.url: "/book?a=1" => "/book?a=1&b=2"`,
+			`+ request.query[0].name: "page"`,
+			`- request.headers[1].name: "Accept"`,
+			`- request.query: []`,
+		})
+
+		diffs, err = DiffJson(newJson, newJson)
+		So(err, ShouldBeNil)
+		So(diffs, ShouldBeEmpty)
+
+		_, err = DiffJson("", newJson)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+// DefaultStateFile 建议使用的本地状态文件，--state 参数默认为空，不使用状态文件
+const DefaultStateFile = ".goshowdoc-state.json"
+
+// StateVersion 状态文件的版本
+const StateVersion = 1
+
+// State 本地状态文件，记录每个 ShowDoc 项目中已经上传的文档内容的哈希值，用于跳过内容没有变化的文档。
+// 不同的 ShowDoc 地址和项目分开记录，项目标识为地址和 api_key 的哈希值，不在文件中保存认证凭证。
+type State struct {
+	Version  int                          `json:"version"`
+	Projects map[string]map[string]string `json:"projects"` // key=项目标识，值为文档名称（目录/标题）对应的内容哈希值
+
+	fileName string
+	project  string
+}
+
+// LoadState 读取本地状态文件，文件不存在时返回空的状态
+//
+// @param fileName 为空时不读取也不保存状态文件
+func LoadState(fileName string, client *runapi.Client) (*State, error) {
+	state := &State{
+		Version:  StateVersion,
+		Projects: make(map[string]map[string]string),
+		fileName: fileName,
+		project:  projectKey(client),
+	}
+	if fileName == "" {
+		return state, nil
+	}
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("解析状态文件 %s 出错: %v", fileName, err)
+	}
+	if state.Version > StateVersion {
+		return nil, fmt.Errorf("不支持的状态文件版本 %d，请升级 goshowdoc", state.Version)
+	}
+	if state.Projects == nil {
+		state.Projects = make(map[string]map[string]string)
+	}
+	return state, nil
+}
+
+// Enabled 是否使用状态文件
+func (s *State) Enabled() bool {
+	return s.fileName != ""
+}
+
+// Hash 当前项目中文档上次上传的内容哈希值
+func (s *State) Hash(name string) (string, bool) {
+	hash, ok := s.Projects[s.project][name]
+	return hash, ok
+}
+
+// SetHash 记录当前项目中文档上传的内容哈希值
+func (s *State) SetHash(name, hash string) {
+	pages := s.Projects[s.project]
+	if pages == nil {
+		pages = make(map[string]string)
+		s.Projects[s.project] = pages
+	}
+	pages[name] = hash
+}
+
+// Delete 删除当前项目中文档的记录
+func (s *State) Delete(name string) {
+	delete(s.Projects[s.project], name)
+}
+
+// Save 保存状态文件
+func (s *State) Save() error {
+	if !s.Enabled() {
+		return nil
+	}
+	data, _ := json.MarshalIndent(s, "", "  ")
+	return os.WriteFile(s.fileName, data, 0644)
+}
+
+// projectKey ShowDoc 项目的标识
+func projectKey(client *runapi.Client) string {
+	sum := sha256.Sum256([]byte(client.Host + "\n" + client.ApiKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// contentHash 文档内容的哈希值，包含文档排序
+func contentHash(order, content string) string {
+	sum := sha256.Sum256([]byte(order + "\n" + content))
+	return hex.EncodeToString(sum[:])
+}
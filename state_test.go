@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+func TestState(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), DefaultStateFile)
+	client := runapi.NewClient("http://showdoc.test", "key", "token")
+	another := runapi.NewClient("http://showdoc.test", "another", "token")
+
+	Convey("测试读写状态文件", t, func() {
+		state, err := LoadState(fileName, client)
+		So(err, ShouldBeNil)
+		So(state.Enabled(), ShouldBeTrue)
+		_, ok := state.Hash("书籍/列表")
+		So(ok, ShouldBeFalse)
+
+		state.SetHash("书籍/列表", "hash1")
+		state.SetHash("书籍/详情", "hash2")
+		state.Delete("书籍/详情")
+		So(state.Save(), ShouldBeNil)
+
+		state, err = LoadState(fileName, client)
+		So(err, ShouldBeNil)
+		hash, ok := state.Hash("书籍/列表")
+		So(ok, ShouldBeTrue)
+		So(hash, ShouldEqual, "hash1")
+		_, ok = state.Hash("书籍/详情")
+		So(ok, ShouldBeFalse)
+
+		// 不同项目分开记录
+		state, err = LoadState(fileName, another)
+		So(err, ShouldBeNil)
+		_, ok = state.Hash("书籍/列表")
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("测试不支持的状态文件版本", t, func() {
+		So(os.WriteFile(fileName, []byte(`{"version":2,"projects":{}}`), 0644), ShouldBeNil)
+		_, err := LoadState(fileName, client)
+		So(err, ShouldNotBeNil)
+
+		So(os.WriteFile(fileName, []byte(`{`), 0644), ShouldBeNil)
+		_, err = LoadState(fileName, client)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("测试不使用状态文件", t, func() {
+		state, err := LoadState("", client)
+		So(err, ShouldBeNil)
+		So(state.Enabled(), ShouldBeFalse)
+		state.SetHash("书籍/列表", "hash1")
+		So(state.Save(), ShouldBeNil)
+	})
+}
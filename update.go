@@ -1,30 +1,526 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
 	"github.com/whaios/goshowdoc/log"
 	"github.com/whaios/goshowdoc/parser"
 	"github.com/whaios/goshowdoc/runapi"
 )
 
-// Update 更新文档
-func Update(searchDir string) {
-	log.Info("解析Go源码文件 %s", searchDir)
+// ParseOptions 解析 Go 源码注释的参数
+type ParseOptions struct {
+	Dirs           []string // 搜索 Go 源码文件的目录
+	RouteDirs      []string // 路由注册代码所在的目录
+	Exclude        []string // 不解析注释的文件，glob 格式
+	CatalogPrefix  string   // 所有文档的目录前缀
+	IncludeTags    []string // 只保留有任意一个标签的文档，为空时不限制
+	ExcludeTags    []string // 去掉有任意一个标签的文档
+	InferTypes     bool     // 从方法体中推断请求和返回类型
+	StrictPathVars bool     // @path_var 注释的参数不在 url 中时返回错误
+	Dialect        string   // 注释的语法：showdoc 或 swag
+	Prefix         string   // 注释标签的命名空间前缀，如：showdoc.
+	StrictPrefix   bool     // 忽略没有前缀的注释标签
+}
+
+// parseApiDoc 解析 Go 源码注释生成文档
+func parseApiDoc(opts ParseOptions) (*parser.Parser, error) {
+	dialect, err := parser.ParseDialect(opts.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Dirs) == 0 {
+		return nil, errors.New("没有设置搜索 Go 源码文件的目录，请使用 --dir 参数或在配置文件中设置 dirs")
+	}
+	log.Info("解析Go源码文件 %s", strings.Join(opts.Dirs, ", "))
 	p := parser.NewParser()
-	if err := p.ParseApiDoc(searchDir); err != nil {
-		log.Error(err.Error())
-		return
+	p.Dialect = dialect
+	p.AnnotationPrefix = opts.Prefix
+	p.StrictPrefix = opts.StrictPrefix
+	p.RouteDirs = opts.RouteDirs
+	p.InferTypes = opts.InferTypes
+	p.StrictPathVars = opts.StrictPathVars
+	p.Exclude = opts.Exclude
+	if err := p.ParseApiDoc(opts.Dirs...); err != nil {
+		return nil, err
+	}
+	if len(opts.IncludeTags) > 0 || len(opts.ExcludeTags) > 0 {
+		total := len(p.Docs)
+		p.FilterTags(opts.IncludeTags, opts.ExcludeTags)
+		log.Info("按标签过滤文档，保留 %d 个，去掉 %d 个", len(p.Docs), total-len(p.Docs))
+	}
+	if prefix := strings.Trim(opts.CatalogPrefix, "/"); prefix != "" {
+		for _, doc := range p.Docs {
+			doc.Catalog = strings.TrimSuffix(prefix+"/"+doc.Catalog, "/")
+		}
+	}
+	return p, nil
+}
+
+// UpdateOptions 更新文档的参数
+type UpdateOptions struct {
+	ItemId    string // ShowDoc 项目 id，用于查询项目中已有的文档，需要同时设置 SessionId
+	DryRun    bool   // 试运行，只输出将要新建和更新的文档，不修改 ShowDoc 中的文档
+	Diff      bool   // 更新前查询项目中已有的文档，输出字段级别的差异
+	StateFile string // 本地状态文件，记录已经上传的文档内容的哈希值，为空时不使用
+	LockFile  string // 锁文件，记录文档 id 对应的 ShowDoc 页面，为空时不使用
+	Force     bool   // 上传所有文档，不跳过内容没有变化的文档
+	Workers   int    // 同时上传的文档数量，小于 1 时为 1
+
+	Prune          bool   // 清理 ShowDoc 中本次没有生成的文档
+	PruneMode      string // 清理的方式：archive 或 delete
+	ArchiveCatalog string // 归档目录
+	Yes            bool   // 清理文档时不需要确认
+}
+
+// Update 更新文档，解析出错或有文档更新失败时返回错误
+//
+// @param targets 更新文档的项目，最后一个为默认项目
+func Update(ctx context.Context, targets []*Target, opts ParseOptions, updateOpts UpdateOptions) error {
+	if err := checkPruneTags(updateOpts, opts.IncludeTags, opts.ExcludeTags); err != nil {
+		return err
+	}
+	p, err := parseApiDoc(opts)
+	if err != nil {
+		return err
+	}
+	return publishTargets(ctx, targets, p.Docs, updateOpts)
+}
+
+// 文档的变化
+const (
+	changeUnknown   = iota // 没有状态记录，不能确定是新建还是更新
+	changeCreated          // 新建
+	changeUpdated          // 更新
+	changeUnchanged        // 无变化
+)
+
+// docChange 文档和 ShowDoc 中已有的文档比较的结果
+type docChange struct {
+	Doc     *parser.ApiDoc
+	Content string // RunApi 文档内容
+	Hash    string // 文档内容的哈希值
+	Status  int    // 文档的变化，如：changeCreated
+	Diffs   []*runapi.FieldDiff
+	Note    string // 补充说明
+
+	PageId      string // 锁文件中记录的页面 id
+	RenamedFrom string // 修改了标题或目录时，锁文件中记录的原来的目录和标题
+}
+
+// publisher 将文档更新到 ShowDoc
+type publisher struct {
+	ctx    context.Context
+	client *runapi.Client
+	opts   UpdateOptions
+	state  *State
+	lock   *Lock
+
+	catalogMu sync.Mutex // 创建目录时修改项目的目录列表
+}
+
+// uploadResult 上传文档的结果
+type uploadResult struct {
+	Change *docChange
+	PageId string
+	Err    error
+}
+
+// publish 将文档更新到 ShowDoc，跳过内容没有变化的文档。试运行或比较差异时先输出每个文档的变化
+func publish(ctx context.Context, client *runapi.Client, docs []*parser.ApiDoc, opts UpdateOptions) error {
+	state, err := LoadState(opts.StateFile, client)
+	if err != nil {
+		return err
+	}
+	lock, err := LoadLock(opts.LockFile, client)
+	if err != nil {
+		return err
+	}
+	p := &publisher{ctx: ctx, client: client, opts: opts, state: state, lock: lock}
+	return p.publish(docs)
+}
+
+func (p *publisher) publish(docs []*parser.ApiDoc) error {
+	opts, state, lock := p.opts, p.state, p.lock
+	changes, err := p.diffDocs(docs)
+	if err != nil {
+		return err
+	}
+	if opts.DryRun || opts.Diff {
+		printChanges(changes, opts.Diff)
+		if opts.DryRun {
+			log.Info(changeSummary(changes))
+			if opts.Prune {
+				if err := p.prune(docs); err != nil {
+					return err
+				}
+			}
+			log.Success("试运行完成，没有修改 ShowDoc 中的文档")
+			return nil
+		}
+	}
+
+	var uploads []*docChange
+	for _, change := range changes {
+		if change.Status == changeUnchanged && !opts.Force {
+			log.Debug("跳过没有变化的文档 %s", change.Doc.Name())
+			state.SetHash(change.Doc.Name(), change.Hash)
+			continue
+		}
+		uploads = append(uploads, change)
+	}
+	item, err := p.renameItem(uploads)
+	if err != nil {
+		return err
+	}
+	var failed []*uploadResult
+	for _, result := range p.uploadDocs(uploads, item) {
+		doc := result.Change.Doc
+		if result.Err != nil {
+			failed = append(failed, result)
+			continue
+		}
+		if result.Change.RenamedFrom != "" && item != nil {
+			state.Delete(result.Change.RenamedFrom)
+		}
+		state.SetHash(doc.Name(), result.Change.Hash)
+		lock.SetPage(doc.Id, result.PageId, doc.Name())
+	}
+	if err := state.Save(); err != nil {
+		return fmt.Errorf("保存状态文件失败: %s", err.Error())
+	}
+	if err := p.lockPages(docs); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		sort.Slice(failed, func(i, j int) bool {
+			return failed[i].Change.Doc.Name() < failed[j].Change.Doc.Name()
+		})
+		log.Warn("共 %d 个文档，其中 %d 个更新失败：", len(uploads), len(failed))
+		for _, result := range failed {
+			log.Error("  %s: %s", result.Change.Doc.Name(), result.Err.Error())
+		}
+		return fmt.Errorf("%d 个文档更新失败，已经上传的文档下次运行时会跳过", len(failed))
+	}
+	log.Success("更新完成，%s", changeSummary(changes))
+	if opts.Prune {
+		return p.prune(docs)
+	}
+	return nil
+}
+
+// diffDocs 比较文档和 ShowDoc 中已有的文档。
+// 设置了项目 id 和 SessionId 或比较差异时，查询项目中已有的文档内容进行比较，否则和本地状态文件中记录的内容哈希值比较。
+// 内容哈希值和状态文件中的记录相同的文档不再查询，其他文档使用 Workers 个 goroutine 同时查询
+func (p *publisher) diffDocs(docs []*parser.ApiDoc) ([]*docChange, error) {
+	opts, state, lock := p.opts, p.state, p.lock
+	var pageIds map[string]string
+	if opts.Diff && (opts.ItemId == "" || p.client.SSID == "") {
+		return nil, errors.New("比较差异需要设置 --item-id 和 --ssid 参数")
+	}
+	if opts.ItemId != "" && p.client.SSID != "" {
+		item, err := p.client.ItemInfo(p.ctx, opts.ItemId)
+		if err != nil {
+			return nil, fmt.Errorf("查询项目[%s]失败: %s", opts.ItemId, err.Error())
+		}
+		pageIds = item.PageIds()
+	}
+
+	changes := make([]*docChange, 0, len(docs))
+	var remotes []*remotePage
+	for _, doc := range docs {
+		content := apiDocToPageContent(doc).String()
+		change := &docChange{Doc: doc, Content: content, Hash: contentHash(doc.Order, content)}
+		changes = append(changes, change)
+		if page := lock.Page(doc.Id); page != nil {
+			change.PageId = page.PageId
+			if page.Name != doc.Name() {
+				change.RenamedFrom = page.Name
+				change.Note = "原文档 " + page.Name
+			}
+		}
+		hash, hashOk := state.Hash(doc.Name())
+
+		if pageIds == nil {
+			if change.RenamedFrom != "" {
+				change.Status = changeUpdated
+			} else if hashOk {
+				change.Status = changeUpdated
+				if hash == change.Hash {
+					change.Status = changeUnchanged
+				}
+			} else if state.Enabled() {
+				change.Status = changeCreated
+			}
+			continue
+		}
+
+		pageId, ok := pageIds[doc.Name()]
+		if change.RenamedFrom != "" {
+			// 锁文件中记录的页面已经被删除时新建文档
+			if pageId, ok = change.PageId, hasPageId(pageIds, change.PageId); !ok {
+				change.PageId, change.RenamedFrom, change.Note = "", "", ""
+			}
+		}
+		if !ok {
+			change.Status = changeCreated
+			continue
+		}
+		change.Status = changeUpdated
+		if hashOk && hash == change.Hash && change.RenamedFrom == "" {
+			// 和上次上传的内容相同，不需要查询页面内容
+			change.Status = changeUnchanged
+			continue
+		}
+		remotes = append(remotes, &remotePage{Change: change, PageId: pageId})
 	}
 
-	max := len(p.Docs)
-	for i, doc := range p.Docs {
-		if err := runapi.UpdateByApi(doc.Catalog, doc.Title, doc.Order, apiDocToPageContent(doc).String()); err != nil {
-			log.Error("更新文档[%s/%s]失败: %s", doc.Catalog, doc.Title, err.Error())
-			return
+	p.forEach(len(remotes), func(i int) {
+		remotes[i].Err = p.diffPage(remotes[i].Change, remotes[i].PageId)
+	})
+	for _, remote := range remotes {
+		if remote.Err != nil {
+			return nil, fmt.Errorf("查询文档[%s]失败: %s", remote.Change.Doc.Name(), remote.Err.Error())
 		}
-		log.DrawProgressBar("更新文档", i+1, max)
 	}
-	log.Success("更新完成")
-	return
+	return changes, nil
+}
+
+// remotePage 需要查询 ShowDoc 中页面内容的文档
+type remotePage struct {
+	Change *docChange
+	PageId string
+	Err    error
+}
+
+// diffPage 查询页面内容，和文档比较字段的差异
+func (p *publisher) diffPage(change *docChange, pageId string) error {
+	page, err := p.client.PageInfo(p.ctx, pageId)
+	if err != nil {
+		return err
+	}
+	page.HtmlUnescape()
+	if change.Diffs, err = runapi.DiffJson(page.PageContent, change.Content); err != nil {
+		change.Note = joinNote(change.Note, "原文档不是 RunApi 格式")
+	} else if len(change.Diffs) == 0 && change.RenamedFrom == "" {
+		change.Status = changeUnchanged
+	} else {
+		change.Note = joinNote(change.Note, fmt.Sprintf("%d 个字段", len(change.Diffs)))
+	}
+	return nil
+}
+
+// hasPageId 项目中是否有指定的页面
+func hasPageId(pageIds map[string]string, pageId string) bool {
+	for _, id := range pageIds {
+		if id == pageId {
+			return true
+		}
+	}
+	return false
+}
+
+func joinNote(note, s string) string {
+	if note == "" {
+		return s
+	}
+	return note + "，" + s
+}
+
+// renameItem 有修改了标题或目录的文档时，查询项目的目录，用于通过 page_id 更新原来的页面。
+// 没有设置项目 id 时不能查询目录，只输出警告，这些文档会作为新的页面上传
+func (p *publisher) renameItem(uploads []*docChange) (*runapi.Item, error) {
+	var renamed []*docChange
+	for _, change := range uploads {
+		if change.RenamedFrom != "" {
+			renamed = append(renamed, change)
+		}
+	}
+	if len(renamed) == 0 {
+		return nil, nil
+	}
+	if p.opts.ItemId == "" || p.client.SSID == "" {
+		for _, change := range renamed {
+			log.Warn("文档 %s 修改了标题或目录（原文档 %s），需要设置 --item-id 和 --ssid 参数才能更新原来的页面，将作为新的页面上传",
+				change.Doc.Name(), change.RenamedFrom)
+		}
+		return nil, nil
+	}
+	item, err := p.client.ItemInfo(p.ctx, p.opts.ItemId)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目[%s]失败: %s", p.opts.ItemId, err.Error())
+	}
+	return item, nil
+}
+
+// uploadDocs 使用 Workers 个 goroutine 同时上传文档，一个文档上传失败时继续上传其他文档。
+// 每个目录的第一个文档先依次上传，避免 ShowDoc 同时创建多个同名目录。
+//
+// @return 按完成顺序排列的上传结果
+func (p *publisher) uploadDocs(uploads []*docChange, item *runapi.Item) []*uploadResult {
+	var first, rest []*docChange
+	catalogs := make(map[string]bool)
+	for _, change := range uploads {
+		if catalogs[change.Doc.Catalog] {
+			rest = append(rest, change)
+			continue
+		}
+		catalogs[change.Doc.Catalog] = true
+		first = append(first, change)
+	}
+
+	var mu sync.Mutex
+	results := make([]*uploadResult, 0, len(uploads))
+	done := func(result *uploadResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, result)
+		log.DrawProgressBar("更新文档", len(results), len(uploads))
+	}
+	upload := func(change *docChange) *uploadResult {
+		pageId, err := p.uploadDoc(change, item)
+		return &uploadResult{Change: change, PageId: pageId, Err: err}
+	}
+	for _, change := range first {
+		done(upload(change))
+	}
+	p.forEach(len(rest), func(i int) {
+		done(upload(rest[i]))
+	})
+	return results
+}
+
+// forEach 使用 Workers 个 goroutine 同时处理 n 个任务，所有任务完成后返回
+func (p *publisher) forEach(n int, fn func(i int)) {
+	workers := p.opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// uploadDoc 上传文档，返回页面 id。修改了标题或目录的文档通过 page_id 更新原来的页面，其他文档通过目录和标题新建或更新
+func (p *publisher) uploadDoc(change *docChange, item *runapi.Item) (string, error) {
+	doc := change.Doc
+	if change.RenamedFrom == "" || item == nil {
+		return p.client.UpdateByApi(p.ctx, doc.Catalog, doc.Title, doc.Order, change.Content)
+	}
+	p.catalogMu.Lock()
+	catId, err := p.ensureCatalog(item, doc.Catalog)
+	p.catalogMu.Unlock()
+	if err != nil {
+		return "", fmt.Errorf("创建目录[%s]失败: %s", doc.Catalog, err.Error())
+	}
+	return change.PageId, p.client.PageSave(p.ctx, &runapi.Page{
+		ItemId:      item.ItemId,
+		CatId:       catId,
+		PageId:      change.PageId,
+		PageTitle:   doc.Title,
+		PageContent: change.Content,
+		SNumber:     doc.Order,
+	})
+}
+
+// lockPages 保存锁文件。ShowDoc 没有返回页面 id 时，查询项目中的文档补充到锁文件中
+func (p *publisher) lockPages(docs []*parser.ApiDoc) error {
+	lock := p.lock
+	if !lock.Enabled() {
+		return nil
+	}
+	var missing bool
+	for _, doc := range docs {
+		if page := lock.Page(doc.Id); page == nil || page.Name != doc.Name() {
+			missing = true
+			break
+		}
+	}
+	if missing && p.opts.ItemId != "" && p.client.SSID != "" {
+		item, err := p.client.ItemInfo(p.ctx, p.opts.ItemId)
+		if err != nil {
+			return fmt.Errorf("查询项目[%s]失败: %s", p.opts.ItemId, err.Error())
+		}
+		pageIds := item.PageIds()
+		for _, doc := range docs {
+			if pageId, ok := pageIds[doc.Name()]; ok {
+				lock.SetPage(doc.Id, pageId, doc.Name())
+			}
+		}
+	}
+	if err := lock.Save(); err != nil {
+		return fmt.Errorf("保存锁文件失败: %s", err.Error())
+	}
+	return nil
+}
+
+// printChanges 输出每个文档的变化，比较差异时输出变化的字段
+func printChanges(changes []*docChange, diff bool) {
+	for i, change := range changes {
+		name := change.Doc.Name()
+		if change.Note != "" {
+			name += "（" + change.Note + "）"
+		}
+		switch change.Status {
+		case changeCreated:
+			log.Success("新建文档(%d) %s", i+1, name)
+		case changeUpdated:
+			log.Warn("更新文档(%d) %s", i+1, name)
+		case changeUnchanged:
+			log.Info("无变化(%d) %s", i+1, name)
+		default:
+			log.Info("新建或更新文档(%d) %s", i+1, name)
+		}
+		if diff {
+			for _, diff := range change.Diffs {
+				printFieldDiff(diff)
+			}
+		}
+	}
+}
+
+// changeSummary 各种变化的文档数量，如：共 4 个文档：新建 1 个，更新 2 个，无变化 1 个
+func changeSummary(changes []*docChange) string {
+	var counts [4]int
+	for _, change := range changes {
+		counts[change.Status]++
+	}
+	summary := fmt.Sprintf("共 %d 个文档：新建 %d 个，更新 %d 个，无变化 %d 个",
+		len(changes), counts[changeCreated], counts[changeUpdated], counts[changeUnchanged])
+	if counts[changeUnknown] > 0 {
+		summary += fmt.Sprintf("，新建或更新 %d 个", counts[changeUnknown])
+	}
+	return summary
+}
+
+// printFieldDiff 输出字段的差异，新增为绿色，删除为红色，修改为黄色
+func printFieldDiff(diff *runapi.FieldDiff) {
+	switch diff.Op {
+	case runapi.DiffAdded:
+		log.Success("    %s", diff.String())
+	case runapi.DiffRemoved:
+		log.Error("    %s", diff.String())
+	default:
+		log.Warn("    %s", diff.String())
+	}
 }
 
 func apiDocToPageContent(doc *parser.ApiDoc) *runapi.PageContent {
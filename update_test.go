@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/whaios/goshowdoc/parser"
+	"github.com/whaios/goshowdoc/runapi"
+)
+
+// fakeShowDoc 模拟 ShowDoc 接口，记录收到的请求
+type fakeShowDoc struct {
+	mu       sync.Mutex
+	item     *runapi.Item      // /api/item/info 返回的项目
+	pages    map[string]string // page_id 对应的页面内容
+	requests map[string]int    // 每个接口收到的请求数
+	failed   map[string]bool   // 返回错误码的接口
+}
+
+func newFakeShowDoc(item *runapi.Item) *fakeShowDoc {
+	return &fakeShowDoc{item: item, pages: make(map[string]string), requests: make(map[string]int), failed: make(map[string]bool)}
+}
+
+func (f *fakeShowDoc) RoundTrip(req *http.Request) (*http.Response, error) {
+	_ = req.ParseForm()
+	api := req.URL.Query().Get("s")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests[api]++
+
+	result := map[string]interface{}{"error_code": 0}
+	if f.failed[api] {
+		result = map[string]interface{}{"error_code": 10101, "error_message": "没有权限"}
+	}
+	switch api {
+	case "/api/item/info":
+		result["data"] = f.item
+	case "/api/page/info":
+		pageId := req.PostForm.Get("page_id")
+		result["data"] = &runapi.Page{PageId: pageId, PageContent: f.pages[pageId]}
+	case "/api/item/updateByApi":
+		result["data"] = map[string]string{"page_id": "100"}
+	case "/api/catalog/save":
+		result["data"] = fmt.Sprintf("%d", 1000+f.requests[api])
+	}
+	data, _ := json.Marshal(result)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(string(data))),
+		Request:    req,
+	}, nil
+}
+
+// count 接口收到的请求数
+func (f *fakeShowDoc) count(api string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[api]
+}
+
+// newTestClient 使用模拟的 ShowDoc 接口的客户端
+func newTestClient(fake *fakeShowDoc) *runapi.Client {
+	client := runapi.NewClient("http://showdoc.test", "key", "token")
+	client.SSID = "ssid"
+	client.HttpClient = &http.Client{Transport: fake}
+	return client
+}
+
+// newTestPublisher 使用模拟的 ShowDoc 接口创建 publisher，不读写状态文件和锁文件
+func newTestPublisher(fake *fakeShowDoc, opts UpdateOptions) *publisher {
+	client := newTestClient(fake)
+	state, _ := LoadState("", client)
+	lock, _ := LoadLock("", client)
+	return &publisher{ctx: context.Background(), client: client, opts: opts, state: state, lock: lock}
+}
+
+func newTestDoc(catalog, title, url string) *parser.ApiDoc {
+	doc := &parser.ApiDoc{Catalog: catalog, Title: title, Order: "99"}
+	doc.Request.Method = runapi.MethodGet
+	doc.Request.Url = url
+	doc.Request.ParamMode = runapi.ParamModeUrlEncoded
+	return doc
+}
+
+func TestPublisher_DiffDocs(t *testing.T) {
+	Convey("测试和 ShowDoc 中已有的文档比较", t, func() {
+		unchanged := newTestDoc("书籍", "列表", "/books")
+		sameRemote := newTestDoc("书籍", "详情", "/books/:id")
+		changed := newTestDoc("书籍", "删除", "/books/:id")
+		created := newTestDoc("书籍", "新建", "/books")
+
+		item := &runapi.Item{ItemId: "1"}
+		item.Menu.Catalogs = []*runapi.Catalog{{CatId: "10", CatName: "书籍", Pages: []*runapi.MenuPage{
+			{PageId: "1", PageTitle: "列表"},
+			{PageId: "2", PageTitle: "详情"},
+			{PageId: "3", PageTitle: "删除"},
+		}}}
+		fake := newFakeShowDoc(item)
+		fake.pages["2"] = apiDocToPageContent(sameRemote).String()
+		fake.pages["3"] = apiDocToPageContent(newTestDoc("书籍", "删除", "/books/:bookId")).String()
+
+		p := newTestPublisher(fake, UpdateOptions{ItemId: "1", Workers: 2})
+		content := apiDocToPageContent(unchanged).String()
+		p.state.SetHash(unchanged.Name(), contentHash(unchanged.Order, content))
+		p.state.SetHash(sameRemote.Name(), "old")
+
+		changes, err := p.diffDocs([]*parser.ApiDoc{unchanged, sameRemote, changed, created})
+		So(err, ShouldBeNil)
+		So(changes[0].Status, ShouldEqual, changeUnchanged)
+		So(changes[1].Status, ShouldEqual, changeUnchanged)
+		So(changes[2].Status, ShouldEqual, changeUpdated)
+		So(len(changes[2].Diffs), ShouldBeGreaterThan, 0)
+		So(changes[3].Status, ShouldEqual, changeCreated)
+		// 内容哈希值没有变化的文档和新建的文档不查询页面内容
+		So(fake.count("/api/page/info"), ShouldEqual, 2)
+		So(fake.count("/api/item/info"), ShouldEqual, 1)
+	})
+
+	Convey("测试没有设置项目 id 时和状态文件比较", t, func() {
+		unchanged := newTestDoc("书籍", "列表", "/books")
+		changed := newTestDoc("书籍", "详情", "/books/:id")
+		created := newTestDoc("书籍", "新建", "/books")
+
+		fake := newFakeShowDoc(nil)
+		p := newTestPublisher(fake, UpdateOptions{})
+		p.state.SetHash(unchanged.Name(), contentHash(unchanged.Order, apiDocToPageContent(unchanged).String()))
+		p.state.SetHash(changed.Name(), "old")
+
+		changes, err := p.diffDocs([]*parser.ApiDoc{unchanged, changed, created})
+		So(err, ShouldBeNil)
+		So(changes[0].Status, ShouldEqual, changeUnchanged)
+		So(changes[1].Status, ShouldEqual, changeUpdated)
+		So(changes[2].Status, ShouldEqual, changeUnknown) // 没有使用状态文件，不能确定是否新建
+		So(fake.count("/api/item/info"), ShouldEqual, 0)
+	})
+
+	Convey("测试设置了项目 id 但没有设置 SessionId 时和状态文件比较", t, func() {
+		doc := newTestDoc("书籍", "列表", "/books")
+		fake := newFakeShowDoc(&runapi.Item{ItemId: "1"})
+		p := newTestPublisher(fake, UpdateOptions{ItemId: "1"})
+		p.client.SSID = ""
+		p.state.SetHash(doc.Name(), contentHash(doc.Order, apiDocToPageContent(doc).String()))
+
+		changes, err := p.diffDocs([]*parser.ApiDoc{doc})
+		So(err, ShouldBeNil)
+		So(changes[0].Status, ShouldEqual, changeUnchanged)
+		So(fake.count("/api/item/info"), ShouldEqual, 0)
+
+		p.opts.Diff = true
+		_, err = p.diffDocs([]*parser.ApiDoc{doc})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	Convey("测试跳过内容没有变化的文档", t, func() {
+		unchanged := newTestDoc("书籍", "列表", "/books")
+		changed := newTestDoc("书籍", "详情", "/books/:id")
+
+		fake := newFakeShowDoc(nil)
+		p := newTestPublisher(fake, UpdateOptions{Workers: 2})
+		p.state.SetHash(unchanged.Name(), contentHash(unchanged.Order, apiDocToPageContent(unchanged).String()))
+		p.state.SetHash(changed.Name(), "old")
+
+		So(p.publish([]*parser.ApiDoc{unchanged, changed}), ShouldBeNil)
+		So(fake.count("/api/item/updateByApi"), ShouldEqual, 1)
+		hash, _ := p.state.Hash(changed.Name())
+		So(hash, ShouldEqual, contentHash(changed.Order, apiDocToPageContent(changed).String()))
+
+		p.opts.Force = true
+		So(p.publish([]*parser.ApiDoc{unchanged, changed}), ShouldBeNil)
+		So(fake.count("/api/item/updateByApi"), ShouldEqual, 3)
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("测试解析出错时返回错误", t, func() {
+		targets := []*Target{{Client: newTestClient(newFakeShowDoc(nil))}}
+		So(Update(ctx, targets, ParseOptions{}, UpdateOptions{}), ShouldNotBeNil)
+	})
+
+	Convey("测试清理文档时不能按标签过滤文档", t, func() {
+		fake := newFakeShowDoc(nil)
+		targets := []*Target{{Client: newTestClient(fake)}}
+		opts := ParseOptions{Dirs: []string{"."}, IncludeTags: []string{"public"}}
+		So(Update(ctx, targets, opts, UpdateOptions{Prune: true}), ShouldNotBeNil)
+		So(Load(ctx, targets, "", nil, []string{"internal"}, UpdateOptions{Prune: true}), ShouldNotBeNil)
+		So(len(fake.requests), ShouldEqual, 0)
+	})
+
+	Convey("测试有文档更新失败时返回错误", t, func() {
+		fake := newFakeShowDoc(nil)
+		fake.failed["/api/item/updateByApi"] = true
+		targets := []*Target{{Client: newTestClient(fake)}}
+		docs := []*parser.ApiDoc{newTestDoc("书籍", "列表", "/books"), newTestDoc("书籍", "详情", "/books/:id")}
+		So(publishTargets(ctx, targets, docs, UpdateOptions{Workers: 2}), ShouldNotBeNil)
+		So(fake.count("/api/item/updateByApi"), ShouldEqual, 2)
+	})
+}
+
+func TestPublisher_DryRun(t *testing.T) {
+	Convey("测试试运行不修改 ShowDoc 中的文档", t, func() {
+		item := &runapi.Item{ItemId: "1"}
+		item.Menu.Catalogs = []*runapi.Catalog{{CatId: "10", CatName: "书籍", Pages: []*runapi.MenuPage{
+			{PageId: "1", PageTitle: "列表"},
+		}}}
+		fake := newFakeShowDoc(item)
+		fake.pages["1"] = apiDocToPageContent(newTestDoc("书籍", "列表", "/list")).String()
+
+		p := newTestPublisher(fake, UpdateOptions{ItemId: "1", DryRun: true, Diff: true})
+		docs := []*parser.ApiDoc{newTestDoc("书籍", "列表", "/books"), newTestDoc("书籍", "新建", "/books")}
+		So(p.publish(docs), ShouldBeNil)
+		So(fake.count("/api/page/info"), ShouldEqual, 1)
+		So(fake.count("/api/item/updateByApi"), ShouldEqual, 0)
+		So(fake.count("/api/page/save"), ShouldEqual, 0)
+		_, ok := p.state.Hash("书籍/新建")
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("测试比较差异需要设置项目 id", t, func() {
+		p := newTestPublisher(newFakeShowDoc(nil), UpdateOptions{Diff: true})
+		So(p.publish([]*parser.ApiDoc{newTestDoc("书籍", "列表", "/books")}), ShouldNotBeNil)
+	})
+}
+
+func TestChangeSummary(t *testing.T) {
+	Convey("测试统计文档的变化", t, func() {
+		changes := []*docChange{
+			{Status: changeCreated},
+			{Status: changeUpdated},
+			{Status: changeUpdated},
+			{Status: changeUnchanged},
+		}
+		So(changeSummary(changes), ShouldEqual, "共 4 个文档：新建 1 个，更新 2 个，无变化 1 个")
+
+		changes = append(changes, &docChange{Status: changeUnknown})
+		So(changeSummary(changes), ShouldEqual, "共 5 个文档：新建 1 个，更新 2 个，无变化 1 个，新建或更新 1 个")
+	})
+}